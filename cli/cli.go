@@ -8,11 +8,14 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
 )
 
+const defaultTerminalWidth = 120
+
 var verbose bool
 var debug bool
 var ocBinary string
@@ -43,6 +46,29 @@ func DebugMsg(messages ...string) {
 	}
 }
 
+// TerminalWidth returns the width of the terminal, as reported by
+// "stty size". If STDOUT is not a terminal (e.g. when piped or in CI), a
+// default width is used so that the output does not depend on the
+// environment. If "stty size" fails, $COLUMNS or the default width is used.
+func TerminalWidth() int {
+	if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return defaultTerminalWidth
+	}
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	if out, err := cmd.Output(); err == nil {
+		if parts := strings.Fields(string(out)); len(parts) == 2 {
+			if width, err := strconv.Atoi(parts[1]); err == nil && width > 0 {
+				return width
+			}
+		}
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultTerminalWidth
+}
+
 func ExecOcCmd(args []string, namespace string, selector string) *exec.Cmd {
 	if len(namespace) > 0 {
 		args = append(args, "--namespace="+namespace)
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTerminalWidthWithoutTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	columns := os.Getenv("COLUMNS")
+	os.Setenv("COLUMNS", "42")
+	defer os.Setenv("COLUMNS", columns)
+
+	if width := TerminalWidth(); width != defaultTerminalWidth {
+		t.Errorf("Got width %d, want %d when STDOUT is not a terminal", width, defaultTerminalWidth)
+	}
+}
@@ -9,6 +9,10 @@ import (
 	"strings"
 )
 
+// errNamespaceNotFound is returned by checkOcNamespace if the namespace does
+// not exist.
+var errNamespaceNotFound = errors.New("Namespace not found")
+
 type GlobalOptions struct {
 	Verbose        bool
 	Debug          bool
@@ -24,7 +28,11 @@ type GlobalOptions struct {
 	PrivateKey     string
 	Passphrase     string
 	Force          bool
+	RulesFile      string
 	IsLoggedIn     bool
+	// NamespaceMissing is true if the namespace does not exist yet, which
+	// is only allowed for compare options with CreateNamespace.
+	NamespaceMissing bool
 }
 
 type CompareOptions struct {
@@ -32,56 +40,156 @@ type CompareOptions struct {
 	Labels                  string
 	Params                  []string
 	ParamFiles              []string
+	ExplainParams           []string
 	Diff                    string
 	IgnorePaths             []string
 	IgnoreUnknownParameters bool
 	UpsertOnly              bool
+	IgnoreDefaults          bool
+	RevealSecrets           bool
+	ClusterScoped           bool
+	CreateNamespace         bool
+	NamespaceDisplayName    string
+	NamespaceDescription    string
+	NamespaceLabels         []string
+	NamespaceAnnotations    []string
+	Templates               []string
 	Resource                string
 }
 
+type AdoptOptions struct {
+	*CompareOptions
+	TemplateFile string
+}
+
 type ExportOptions struct {
 	*GlobalOptions
-	Resource string
+	Resource       string
+	OutputDir      string
+	SplitBy        string
+	SplitLabel     string
+	Parameterize   bool
+	ParamRules     []string
+	EncryptSecrets bool
+	StripDefaults  bool
+}
+
+// FileSection holds the flags of a namespace section of the Tailorfile,
+// which starts with a line like "[foo-dev]" and ends with the next section.
+// Flags contains the flags declared outside of any section, overridden by
+// the flags of the section, and the namespace.
+type FileSection struct {
+	Namespace string
+	Flags     map[string]string
+}
+
+// CloneOptions are the options of the clone command, which copies the
+// resources of namespace From into namespace To.
+type CloneOptions struct {
+	*GlobalOptions
+	From        string
+	To          string
+	Resource    string
+	SkipSecrets bool
+	SkipPVCs    bool
+	DryRun      bool
+}
+
+// PromoteOptions are the options of the promote command. From and To hold
+// the compare options of the source and the target namespace.
+type PromoteOptions struct {
+	From       *CompareOptions
+	To         *CompareOptions
+	CopyParams []string
 }
 
+// GetFileFlags returns the flags declared in the Tailorfile outside of any
+// namespace section.
 func GetFileFlags(filename string, verboseOrDebug bool) (map[string]string, error) {
+	fileFlags, _, err := readFile(filename, verboseOrDebug)
+	return fileFlags, err
+}
+
+// GetFileSections returns the namespace sections of the Tailorfile in the
+// order they are declared.
+func GetFileSections(filename string, verboseOrDebug bool) ([]*FileSection, error) {
+	fileFlags, sections, err := readFile(filename, verboseOrDebug)
+	if err != nil {
+		return sections, err
+	}
+	for _, section := range sections {
+		for key, value := range fileFlags {
+			if _, ok := section.Flags[key]; !ok {
+				section.Flags[key] = value
+			}
+		}
+		section.Flags["namespace"] = section.Namespace
+	}
+	return sections, nil
+}
+
+func readFile(filename string, verboseOrDebug bool) (map[string]string, []*FileSection, error) {
 	fileFlags := make(map[string]string)
+	sections := []*FileSection{}
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		if filename == "Tailorfile" {
 			if verboseOrDebug {
 				PrintBluef("--> No file '%s' found.\n", filename)
 			}
-			return fileFlags, nil
+			return fileFlags, sections, nil
 		}
-		return fileFlags, err
+		return fileFlags, sections, err
 	}
 
 	b, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return fileFlags, err
+		return fileFlags, sections, err
 	}
 	content := string(b)
 	text := strings.TrimSuffix(content, "\n")
 	lines := strings.Split(text, "\n")
 
+	flags := fileFlags
 	for _, untrimmedLine := range lines {
 		line := strings.TrimSpace(untrimmedLine)
 		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
 			continue
 		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			namespace := strings.TrimSpace(line[1 : len(line)-1])
+			if len(namespace) == 0 {
+				return fileFlags, sections, errors.New("Namespace sections must not be empty")
+			}
+			for _, section := range sections {
+				if section.Namespace == namespace {
+					return fileFlags, sections, fmt.Errorf("Namespace section [%s] is declared multiple times", namespace)
+				}
+			}
+			section := &FileSection{Namespace: namespace, Flags: make(map[string]string)}
+			sections = append(sections, section)
+			flags = section.Flags
+			continue
+		}
 		pair := strings.SplitN(line, " ", 2)
 		if len(pair) == 2 {
 			key := pair[0]
 			value := strings.TrimSpace(pair[1])
-			if val, ok := fileFlags[key]; ok {
+			// Rules are loaded once and apply to all namespaces.
+			if key == "rules-file" && len(sections) > 0 {
+				return fileFlags, sections, fmt.Errorf(
+					"Namespace section [%s] must not set rules-file, as rules apply to all namespaces",
+					sections[len(sections)-1].Namespace,
+				)
+			}
+			if val, ok := flags[key]; ok {
 				value = val + "," + value
 			}
-			fileFlags[key] = value
+			flags[key] = value
 		} else {
-			fileFlags["resource"] = pair[0]
+			flags["resource"] = pair[0]
 		}
 	}
-	return fileFlags, nil
+	return fileFlags, sections, nil
 }
 
 func (o *GlobalOptions) UpdateWithFile(fileFlags map[string]string) {
@@ -124,9 +232,12 @@ func (o *GlobalOptions) UpdateWithFile(fileFlags map[string]string) {
 	if fileFlags["force"] == "true" {
 		o.Force = true
 	}
+	if val, ok := fileFlags["rules-file"]; ok {
+		o.RulesFile = val
+	}
 }
 
-func (o *GlobalOptions) UpdateWithFlags(verboseFlag bool, debugFlag bool, nonInteractiveFlag bool, ocBinaryFlag string, namespaceFlag string, selectorFlag string, excludeFlag string, templateDirFlag []string, paramDirFlag []string, publicKeyDirFlag string, privateKeyFlag string, passphraseFlag string, forceFlag bool) {
+func (o *GlobalOptions) UpdateWithFlags(verboseFlag bool, debugFlag bool, nonInteractiveFlag bool, ocBinaryFlag string, namespaceFlag string, selectorFlag string, excludeFlag string, templateDirFlag []string, paramDirFlag []string, publicKeyDirFlag string, privateKeyFlag string, passphraseFlag string, forceFlag bool, rulesFileFlag string) {
 	if verboseFlag {
 		o.Verbose = true
 	}
@@ -182,6 +293,10 @@ func (o *GlobalOptions) UpdateWithFlags(verboseFlag bool, debugFlag bool, nonInt
 	if forceFlag {
 		o.Force = true
 	}
+
+	if len(rulesFileFlag) > 0 {
+		o.RulesFile = rulesFileFlag
+	}
 }
 
 func (o *GlobalOptions) Process() error {
@@ -191,10 +306,18 @@ func (o *GlobalOptions) Process() error {
 	if !o.checkOcBinary() {
 		return fmt.Errorf("No such oc binary: %s", o.OcBinary)
 	}
+	if len(o.RulesFile) > 0 {
+		if _, err := os.Stat(o.RulesFile); os.IsNotExist(err) {
+			return fmt.Errorf("Rules file %s does not exist", o.RulesFile)
+		}
+	}
 	return nil
 }
 
-func (o *GlobalOptions) setupClusterCommunication() error {
+// setupClusterCommunication checks that the user is logged in, and sets or
+// verifies the namespace. If allowMissingNamespace is true, a given
+// namespace which does not exist yet is marked as missing instead.
+func (o *GlobalOptions) setupClusterCommunication(allowMissingNamespace bool) error {
 	if !o.checkLoggedIn() {
 		return errors.New("You need to login with 'oc login' first")
 	}
@@ -215,8 +338,14 @@ func (o *GlobalOptions) setupClusterCommunication() error {
 		o.Namespace = n
 	} else {
 		err := checkOcNamespace(o.Namespace)
-		if err != nil {
-			return fmt.Errorf("No such project: %s", o.Namespace)
+		if err == errNamespaceNotFound {
+			if !allowMissingNamespace {
+				return fmt.Errorf("No such project: %s", o.Namespace)
+			}
+			VerboseMsg("Project", o.Namespace, "does not exist yet")
+			o.NamespaceMissing = true
+		} else if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -259,15 +388,42 @@ func (o *CompareOptions) UpdateWithFile(fileFlags map[string]string) {
 	if fileFlags["upsert-only"] == "true" {
 		o.UpsertOnly = true
 	}
+	if fileFlags["ignore-defaults"] == "true" {
+		o.IgnoreDefaults = true
+	}
+	if fileFlags["reveal-secrets"] == "true" {
+		o.RevealSecrets = true
+	}
+	if fileFlags["cluster-scoped"] == "true" {
+		o.ClusterScoped = true
+	}
+	if fileFlags["create-namespace"] == "true" {
+		o.CreateNamespace = true
+	}
+	if val, ok := fileFlags["namespace-display-name"]; ok {
+		o.NamespaceDisplayName = val
+	}
+	if val, ok := fileFlags["namespace-description"]; ok {
+		o.NamespaceDescription = val
+	}
+	if val, ok := fileFlags["namespace-labels"]; ok {
+		o.NamespaceLabels = strings.Split(val, ",")
+	}
+	if val, ok := fileFlags["namespace-annotations"]; ok {
+		o.NamespaceAnnotations = strings.Split(val, ",")
+	}
 	if val, ok := fileFlags["ignore-path"]; ok {
 		o.IgnorePaths = strings.Split(val, ",")
 	}
+	if val, ok := fileFlags["templates"]; ok {
+		o.Templates = strings.Split(val, ",")
+	}
 	if val, ok := fileFlags["resource"]; ok {
 		o.Resource = val
 	}
 }
 
-func (o *CompareOptions) UpdateWithFlags(labelsFlag string, paramFlag []string, paramFileFlag []string, diffFlag string, ignorePathFlag []string, ignoreUnknownParametersFlag bool, upsertOnlyFlag bool, resourceArg string) {
+func (o *CompareOptions) UpdateWithFlags(labelsFlag string, paramFlag []string, paramFileFlag []string, explainParamFlag []string, diffFlag string, ignorePathFlag []string, ignoreUnknownParametersFlag bool, upsertOnlyFlag bool, ignoreDefaultsFlag bool, revealSecretsFlag bool, clusterScopedFlag bool, createNamespaceFlag bool, resourceArg string) {
 	if len(labelsFlag) > 0 {
 		o.Labels = labelsFlag
 	}
@@ -300,6 +456,9 @@ func (o *CompareOptions) UpdateWithFlags(labelsFlag string, paramFlag []string,
 	if len(paramFileFlag) > 0 {
 		o.ParamFiles = paramFileFlag
 	}
+	if len(explainParamFlag) > 0 {
+		o.ExplainParams = explainParamFlag
+	}
 	if len(diffFlag) > 0 {
 		o.Diff = diffFlag
 	}
@@ -309,6 +468,18 @@ func (o *CompareOptions) UpdateWithFlags(labelsFlag string, paramFlag []string,
 	if upsertOnlyFlag {
 		o.UpsertOnly = true
 	}
+	if ignoreDefaultsFlag {
+		o.IgnoreDefaults = true
+	}
+	if revealSecretsFlag {
+		o.RevealSecrets = true
+	}
+	if clusterScopedFlag {
+		o.ClusterScoped = true
+	}
+	if createNamespaceFlag {
+		o.CreateNamespace = true
+	}
 	if len(ignorePathFlag) > 0 {
 		o.IgnorePaths = ignorePathFlag
 	}
@@ -318,6 +489,32 @@ func (o *CompareOptions) UpdateWithFlags(labelsFlag string, paramFlag []string,
 }
 
 func (o *CompareOptions) Process() error {
+	err := o.validate()
+	if err != nil {
+		return err
+	}
+	return o.setupClusterCommunication(o.CreateNamespace)
+}
+
+// ProcessWithoutCluster is like Process, but does not communicate with the
+// cluster. If no namespace is given, the namespace of the current oc context
+// is read from the local configuration.
+func (o *CompareOptions) ProcessWithoutCluster() error {
+	err := o.validate()
+	if err != nil {
+		return err
+	}
+	if len(o.Namespace) == 0 {
+		n, err := getOcContextNamespace()
+		if err != nil {
+			return fmt.Errorf("Could not determine namespace of current oc context: %s", err)
+		}
+		o.Namespace = n
+	}
+	return nil
+}
+
+func (o *CompareOptions) validate() error {
 	if (len(o.ParamDirs) > 1 || o.ParamDirs[0] != ".") && len(o.ParamFiles) > 0 {
 		return errors.New("You cannot specify both --param-dir and --param-file")
 	}
@@ -328,34 +525,218 @@ func (o *CompareOptions) Process() error {
 			}
 		}
 	}
-	if o.Diff != "text" && o.Diff != "json" {
-		return errors.New("--diff must be either text or json")
+	switch o.Diff {
+	case "text", "json", "structural", "word", "side-by-side":
+	default:
+		return errors.New("--diff must be one of text, json, structural, word or side-by-side")
 	}
 	if strings.Contains(o.Resource, "/") && len(o.Selector) > 0 {
 		DebugMsg("Ignoring selector", o.Selector, "as resource is given")
 		o.Selector = ""
 	}
-	return o.setupClusterCommunication()
+	if o.CreateNamespace && len(o.Namespace) == 0 {
+		return errors.New("--create-namespace requires the namespace to be given")
+	}
+	for _, l := range o.NamespaceLabels {
+		if !strings.Contains(l, "=") {
+			return fmt.Errorf("Namespace label %s must be in the form key=value", l)
+		}
+	}
+	for _, a := range o.NamespaceAnnotations {
+		if !strings.Contains(a, "=") {
+			return fmt.Errorf("Namespace annotation %s must be in the form key=value", a)
+		}
+	}
+	return nil
+}
+
+func (o *AdoptOptions) UpdateWithFile(fileFlags map[string]string) {
+	o.CompareOptions.UpdateWithFile(fileFlags)
+	if val, ok := fileFlags["template-file"]; ok {
+		o.TemplateFile = val
+	}
+}
+
+func (o *AdoptOptions) UpdateWithFlags(templateFileFlag string) {
+	if len(templateFileFlag) > 0 {
+		o.TemplateFile = templateFileFlag
+	}
+}
+
+func (o *AdoptOptions) Process() error {
+	if len(o.TemplateFile) == 0 {
+		return errors.New("--template-file must not be empty")
+	}
+	if strings.Contains(o.TemplateFile, string(os.PathSeparator)) {
+		return errors.New("--template-file must be a filename, not a path")
+	}
+	if !strings.HasSuffix(o.TemplateFile, ".yml") && !strings.HasSuffix(o.TemplateFile, ".yaml") {
+		return errors.New("--template-file must end in .yml or .yaml")
+	}
+	return o.CompareOptions.Process()
 }
 
 func (o *ExportOptions) UpdateWithFile(fileFlags map[string]string) {
 	if val, ok := fileFlags["resource"]; ok {
 		o.Resource = val
 	}
+	if val, ok := fileFlags["output-dir"]; ok {
+		o.OutputDir = val
+	}
+	if val, ok := fileFlags["split-by"]; ok {
+		o.SplitBy = val
+	}
+	if val, ok := fileFlags["split-label"]; ok {
+		o.SplitLabel = val
+	}
+	if fileFlags["parameterize"] == "true" {
+		o.Parameterize = true
+	}
+	if val, ok := fileFlags["param-rule"]; ok {
+		o.ParamRules = strings.Split(val, ",")
+	}
+	if fileFlags["encrypt-secrets"] == "true" {
+		o.EncryptSecrets = true
+	}
+	if fileFlags["strip-defaults"] == "true" {
+		o.StripDefaults = true
+	}
 }
 
-func (o *ExportOptions) UpdateWithFlags(resourceArg string) {
+func (o *ExportOptions) UpdateWithFlags(outputDirFlag string, splitByFlag string, splitLabelFlag string, parameterizeFlag bool, paramRuleFlag []string, encryptSecretsFlag bool, stripDefaultsFlag bool, resourceArg string) {
+	if len(outputDirFlag) > 0 {
+		o.OutputDir = outputDirFlag
+	}
+	if len(splitByFlag) > 0 {
+		o.SplitBy = splitByFlag
+	}
+	if len(splitLabelFlag) > 0 {
+		o.SplitLabel = splitLabelFlag
+	}
+	if parameterizeFlag {
+		o.Parameterize = true
+	}
+	if len(paramRuleFlag) > 0 {
+		o.ParamRules = paramRuleFlag
+	}
+	if encryptSecretsFlag {
+		o.EncryptSecrets = true
+	}
+	if stripDefaultsFlag {
+		o.StripDefaults = true
+	}
 	if len(resourceArg) > 0 {
 		o.Resource = resourceArg
 	}
 }
 
 func (o *ExportOptions) Process() error {
+	// Defaults are applied only now so that flags can override the file.
+	if len(o.SplitBy) == 0 {
+		o.SplitBy = "kind"
+	}
+	if len(o.SplitLabel) == 0 {
+		o.SplitLabel = "app"
+	}
+	if o.SplitBy != "kind" && o.SplitBy != "label" {
+		return errors.New("--split-by must be either kind or label")
+	}
+	if o.Parameterize && len(o.OutputDir) == 0 {
+		return errors.New("--parameterize requires --output-dir")
+	}
+	if o.EncryptSecrets && len(o.OutputDir) == 0 {
+		return errors.New("--encrypt-secrets requires --output-dir")
+	}
+	if len(o.ParamRules) > 0 && !o.Parameterize {
+		return errors.New("--param-rule requires --parameterize")
+	}
+	if strings.Contains(o.Resource, "/") && len(o.Selector) > 0 {
+		DebugMsg("Ignoring selector", o.Selector, "as resource is given")
+		o.Selector = ""
+	}
+	return o.setupClusterCommunication(false)
+}
+
+func (o *CloneOptions) UpdateWithFile(fileFlags map[string]string) {
+	if val, ok := fileFlags["resource"]; ok {
+		o.Resource = val
+	}
+	if fileFlags["skip-secrets"] == "true" {
+		o.SkipSecrets = true
+	}
+	if fileFlags["skip-pvcs"] == "true" {
+		o.SkipPVCs = true
+	}
+}
+
+func (o *CloneOptions) UpdateWithFlags(fromFlag string, toFlag string, skipSecretsFlag bool, skipPVCsFlag bool, dryRunFlag bool, resourceArg string) {
+	if len(fromFlag) > 0 {
+		o.From = fromFlag
+	}
+	if len(toFlag) > 0 {
+		o.To = toFlag
+	}
+	if skipSecretsFlag {
+		o.SkipSecrets = true
+	}
+	if skipPVCsFlag {
+		o.SkipPVCs = true
+	}
+	if dryRunFlag {
+		o.DryRun = true
+	}
+	if len(resourceArg) > 0 {
+		o.Resource = resourceArg
+	}
+}
+
+func (o *CloneOptions) Process() error {
+	if len(o.From) == 0 || len(o.To) == 0 {
+		return errors.New("--from and --to must not be empty")
+	}
+	if o.From == o.To {
+		return errors.New("--from and --to must be different namespaces")
+	}
 	if strings.Contains(o.Resource, "/") && len(o.Selector) > 0 {
 		DebugMsg("Ignoring selector", o.Selector, "as resource is given")
 		o.Selector = ""
 	}
-	return o.setupClusterCommunication()
+	o.Namespace = o.From
+	if err := o.setupClusterCommunication(false); err != nil {
+		return err
+	}
+	if err := checkOcNamespace(o.To); err != nil {
+		return fmt.Errorf("No such project: %s", o.To)
+	}
+	// The target namespace is the namespace to operate on.
+	o.Namespace = o.To
+	return nil
+}
+
+func (o *PromoteOptions) UpdateWithFlags(copyParamFlag []string) {
+	if len(copyParamFlag) > 0 {
+		o.CopyParams = copyParamFlag
+	}
+}
+
+func (o *PromoteOptions) Process() error {
+	if o.From.Namespace == o.To.Namespace {
+		return errors.New("--from and --to must be different namespaces")
+	}
+	for _, p := range o.CopyParams {
+		if strings.Contains(p, "=") {
+			return fmt.Errorf("--copy-param expects a parameter name, got %s", p)
+		}
+	}
+	for _, c := range []*CompareOptions{o.From, o.To} {
+		if len(c.ParamFiles) > 0 {
+			return fmt.Errorf("Namespace %s uses --param-file, but promote requires param dirs", c.Namespace)
+		}
+		if err := c.Process(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Check that OC client and server version match.
@@ -416,8 +797,29 @@ func getOcNamespace() (string, error) {
 	return strings.TrimSpace(string(n)), err
 }
 
+// getOcContextNamespace returns the namespace of the current oc context
+// without contacting the cluster. It is empty if the context has none.
+func getOcContextNamespace() (string, error) {
+	cmd := ExecPlainOcCmd([]string{"config", "view", "--minify", "--output=jsonpath={..namespace}"})
+	n, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.New(strings.TrimSpace(string(n)))
+	}
+	return strings.TrimSpace(string(n)), nil
+}
+
+// checkOcNamespace returns errNamespaceNotFound if the namespace does not
+// exist, and another error if it cannot be accessed (e.g. due to missing
+// permissions).
 func checkOcNamespace(n string) error {
 	cmd := ExecPlainOcCmd([]string{"project", n, "--short"})
-	_, err := cmd.CombinedOutput()
-	return err
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		output := strings.TrimSpace(string(out))
+		if strings.Contains(output, "does not exist") || strings.Contains(output, "not found") {
+			return errNamespaceNotFound
+		}
+		return fmt.Errorf("Cannot access project %s: %s", n, output)
+	}
+	return nil
 }
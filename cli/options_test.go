@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeOcBinary is a stub of the oc binary, see the script for how to adjust
+// its behaviour.
+const fakeOcBinary = "../testdata/fake-oc/oc"
+
+func TestSetupClusterCommunication(t *testing.T) {
+	defer os.Unsetenv("TAILOR_TEST_PROJECT_OUTPUT")
+
+	tests := map[string]struct {
+		projectOutput         string
+		allowMissingNamespace bool
+		expectedMissing       bool
+		expectedError         string
+	}{
+		"missing namespace allowed": {
+			projectOutput:         `error: A project named "foo-dev" does not exist on "https://example.com".`,
+			allowMissingNamespace: true,
+			expectedMissing:       true,
+		},
+		"missing namespace not allowed": {
+			projectOutput:         `error: A project named "foo-dev" does not exist on "https://example.com".`,
+			allowMissingNamespace: false,
+			expectedError:         "No such project: foo-dev",
+		},
+		"inaccessible namespace": {
+			projectOutput:         `error: You are not a member of project "foo-dev".`,
+			allowMissingNamespace: true,
+			expectedError:         "Cannot access project foo-dev",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv("TAILOR_TEST_PROJECT_OUTPUT", tc.projectOutput)
+			o := &GlobalOptions{OcBinary: fakeOcBinary, Namespace: "foo-dev"}
+			err := o.Process()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.setupClusterCommunication(tc.allowMissingNamespace)
+			if len(tc.expectedError) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Got error %v, want %s", err, tc.expectedError)
+				}
+			} else if err != nil {
+				t.Errorf("Got error %s", err)
+			}
+			if o.NamespaceMissing != tc.expectedMissing {
+				t.Errorf("Got namespace missing %t, want %t", o.NamespaceMissing, tc.expectedMissing)
+			}
+		})
+	}
+}
+
+func TestGetFileSections(t *testing.T) {
+	tests := map[string]struct {
+		content          string
+		expectedFlags    map[string]string
+		expectedSections map[string]map[string]string
+		expectedError    string
+	}{
+		"no sections": {
+			content: "template-dir templates\nparam-dir params\n",
+			expectedFlags: map[string]string{
+				"template-dir": "templates",
+				"param-dir":    "params",
+			},
+			expectedSections: map[string]map[string]string{},
+		},
+		"sections inherit top-level flags": {
+			content: `# Shared by all namespaces
+template-dir templates
+param-dir params
+labels app=foo
+
+[foo-dev]
+param-dir params/dev
+
+[foo-test]
+templates a.yml
+templates b.yml
+`,
+			expectedFlags: map[string]string{
+				"template-dir": "templates",
+				"param-dir":    "params",
+				"labels":       "app=foo",
+			},
+			expectedSections: map[string]map[string]string{
+				"foo-dev": map[string]string{
+					"template-dir": "templates",
+					"param-dir":    "params/dev",
+					"labels":       "app=foo",
+					"namespace":    "foo-dev",
+				},
+				"foo-test": map[string]string{
+					"template-dir": "templates",
+					"param-dir":    "params",
+					"labels":       "app=foo",
+					"templates":    "a.yml,b.yml",
+					"namespace":    "foo-test",
+				},
+			},
+		},
+		"empty section": {
+			content:       "[]\nparam-dir params\n",
+			expectedError: "Namespace sections must not be empty",
+		},
+		"duplicated section": {
+			content:       "[foo-dev]\nparam-dir a\n[foo-dev]\nparam-dir b\n",
+			expectedError: "Namespace section [foo-dev] is declared multiple times",
+		},
+		"rules file in section": {
+			content:       "rules-file rules.yml\n[foo-dev]\nrules-file other.yml\n",
+			expectedError: "Namespace section [foo-dev] must not set rules-file",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "Tailorfile")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			_, err = f.WriteString(tc.content)
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fileFlags, err := GetFileFlags(f.Name(), false)
+			if len(tc.expectedError) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Got error %v, want %s", err, tc.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertFlags(t, "top-level", fileFlags, tc.expectedFlags)
+
+			sections, err := GetFileSections(f.Name(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sections) != len(tc.expectedSections) {
+				t.Fatalf("Got %d sections, want %d", len(sections), len(tc.expectedSections))
+			}
+			for _, section := range sections {
+				expected, ok := tc.expectedSections[section.Namespace]
+				if !ok {
+					t.Errorf("Unexpected section [%s]", section.Namespace)
+					continue
+				}
+				assertFlags(t, "["+section.Namespace+"]", section.Flags, expected)
+			}
+		})
+	}
+}
+
+func assertFlags(t *testing.T, name string, actual map[string]string, expected map[string]string) {
+	if len(actual) != len(expected) {
+		t.Errorf("%s: got flags %v, want %v", name, actual, expected)
+		return
+	}
+	for k, v := range expected {
+		if actual[k] != v {
+			t.Errorf("%s: got %s=%s, want %s", name, k, actual[k], v)
+		}
+	}
+}
+
+func TestExportOptionsSplitBy(t *testing.T) {
+	tests := map[string]struct {
+		fileFlags          map[string]string
+		splitByFlag        string
+		splitLabelFlag     string
+		expectedSplitBy    string
+		expectedSplitLabel string
+	}{
+		"defaults": {
+			fileFlags:          map[string]string{},
+			expectedSplitBy:    "kind",
+			expectedSplitLabel: "app",
+		},
+		"file": {
+			fileFlags:          map[string]string{"split-by": "label", "split-label": "team"},
+			expectedSplitBy:    "label",
+			expectedSplitLabel: "team",
+		},
+		"flags override file with default values": {
+			fileFlags:          map[string]string{"split-by": "label", "split-label": "team"},
+			splitByFlag:        "kind",
+			splitLabelFlag:     "app",
+			expectedSplitBy:    "kind",
+			expectedSplitLabel: "app",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := &ExportOptions{GlobalOptions: &GlobalOptions{OcBinary: fakeOcBinary, Namespace: "foo-dev", IsLoggedIn: true}}
+			err := o.GlobalOptions.Process()
+			if err != nil {
+				t.Fatal(err)
+			}
+			o.UpdateWithFile(tc.fileFlags)
+			o.UpdateWithFlags("", tc.splitByFlag, tc.splitLabelFlag, false, []string{}, false, false, "")
+			err = o.Process()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.SplitBy != tc.expectedSplitBy {
+				t.Errorf("Got split by %s, want %s", o.SplitBy, tc.expectedSplitBy)
+			}
+			if o.SplitLabel != tc.expectedSplitLabel {
+				t.Errorf("Got split label %s, want %s", o.SplitLabel, tc.expectedSplitLabel)
+			}
+		})
+	}
+}
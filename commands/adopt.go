@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/openshift"
+)
+
+// Adopt writes a cleaned template for the targeted resources, marks those
+// resources as managed by Tailor and prints the remaining drift.
+func Adopt(adoptOptions *cli.AdoptOptions) (bool, error) {
+	filter, err := openshift.NewResourceFilter(adoptOptions.Resource, adoptOptions.Selector, adoptOptions.Exclude)
+	if err != nil {
+		return false, err
+	}
+
+	m, items, err := openshift.ExportAsTemplate(filter, adoptOptions.Namespace, adoptOptions.IgnoreDefaults)
+	if err != nil {
+		return false, fmt.Errorf(
+			"Could not export %s resources as template: %s",
+			filter.String(),
+			err,
+		)
+	}
+	if len(items) == 0 {
+		fmt.Println("No resources found to adopt.")
+		return false, nil
+	}
+
+	filename := adoptOptions.TemplateDirs[0] + string(os.PathSeparator) + adoptOptions.TemplateFile
+	if _, err := os.Stat(filename); err == nil && !adoptOptions.Force {
+		return false, fmt.Errorf(
+			"Template %s exists already. Refusing to overwrite it without --force",
+			filename,
+		)
+	}
+
+	fmt.Printf("Adopting %d resource(s) into %s:\n", len(items), filename)
+	for _, item := range items {
+		fmt.Printf("* %s\n", item.FullName())
+	}
+	if !adoptOptions.NonInteractive {
+		c := cli.AskForConfirmation("Write template and mark resources as managed?")
+		if !c {
+			return false, nil
+		}
+	}
+
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return false, fmt.Errorf("Could not marshal template: %s", err)
+	}
+	err = ioutil.WriteFile(filename, b, 0644)
+	if err != nil {
+		return false, fmt.Errorf("Could not write template: %s", err)
+	}
+
+	for _, item := range items {
+		fmt.Printf("Marking %s as managed ... ", item.FullName())
+		err := openshift.MarkAsManaged(item, adoptOptions.Namespace, adoptOptions.TemplateFile)
+		if err != nil {
+			fmt.Println("failed")
+			return false, err
+		}
+		fmt.Println("done")
+	}
+	fmt.Println("")
+
+	updateRequired, _, err := calculateChangeset(adoptOptions.CompareOptions)
+	return updateRequired, err
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/openshift"
+)
+
+// Clone copies the targeted resources of the source namespace into the
+// target namespace, rewriting references to the source namespace. Existing
+// resources in the target namespace are updated, but never deleted.
+func Clone(cloneOptions *cli.CloneOptions) error {
+	filter, err := openshift.NewResourceFilter(cloneOptions.Resource, cloneOptions.Selector, cloneOptions.Exclude)
+	if err != nil {
+		return err
+	}
+	if cloneOptions.SkipSecrets {
+		filter.ExcludedKinds = append(filter.ExcludedKinds, "Secret")
+	}
+	if cloneOptions.SkipPVCs {
+		filter.ExcludedKinds = append(filter.ExcludedKinds, "PersistentVolumeClaim")
+	}
+
+	fmt.Printf(
+		"Cloning resources of OCP namespace %s into OCP namespace %s.\n",
+		cloneOptions.From,
+		cloneOptions.To,
+	)
+
+	_, items, err := openshift.ExportAsTemplate(filter, cloneOptions.From, false)
+	if err != nil {
+		return fmt.Errorf(
+			"Could not export %s resources as template: %s",
+			filter.String(),
+			err,
+		)
+	}
+
+	rewrites := []*openshift.NamespaceRewrite{}
+	objects := []interface{}{}
+	for _, item := range items {
+		if !filter.SatisfiedBy(item) {
+			continue
+		}
+		rewrites = append(rewrites, item.PrepareForClone(cloneOptions.From, cloneOptions.To)...)
+		objects = append(objects, item.Config)
+	}
+	if len(objects) == 0 {
+		fmt.Println("No resources found to clone.")
+		return nil
+	}
+
+	if len(rewrites) > 0 {
+		fmt.Printf("\nRewriting references to namespace %s:\n", cloneOptions.From)
+		for _, r := range rewrites {
+			fmt.Printf("* %s %s: %s → %s\n", r.Item, r.Path, r.From, r.To)
+		}
+	}
+	fmt.Println("")
+
+	b, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      objects,
+	})
+	if err != nil {
+		return fmt.Errorf("Could not marshal cloned resources: %s", err)
+	}
+	templateBasedList, err := openshift.NewTemplateBasedResourceList(filter, b)
+	if err != nil {
+		return err
+	}
+
+	compareOptions := &cli.CompareOptions{
+		GlobalOptions: cloneOptions.GlobalOptions,
+		Diff:          "text",
+	}
+	platformBasedList, err := assemblePlatformBasedResourceList(filter, compareOptions, []*openshift.ResourceItem{})
+	if err != nil {
+		return err
+	}
+
+	changeset, err := compare(platformBasedList, templateBasedList, true, compareOptions.Diff, []string{}, false)
+	if err != nil {
+		return err
+	}
+
+	if cloneOptions.DryRun {
+		fmt.Println("Dry run, no changes have been applied.")
+		return nil
+	}
+	if changeset.Blank() {
+		return nil
+	}
+	if !cloneOptions.NonInteractive {
+		c := cli.AskForConfirmation("Apply changes?")
+		if !c {
+			return nil
+		}
+		fmt.Println("")
+	}
+	err = apply(compareOptions, changeset)
+	if err != nil {
+		return fmt.Errorf("Clone aborted: %s", err)
+	}
+	return nil
+}
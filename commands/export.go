@@ -2,18 +2,28 @@ package commands
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 
+	"github.com/ghodss/yaml"
 	"github.com/opendevstack/tailor/cli"
 	"github.com/opendevstack/tailor/openshift"
+	"github.com/opendevstack/tailor/utils"
 )
 
 // Export prints an export of targeted resources to STDOUT.
+// If an output directory is given, the export is split into several
+// templates which are written into that directory instead.
 func Export(exportOptions *cli.ExportOptions) error {
 	filter, err := openshift.NewResourceFilter(exportOptions.Resource, exportOptions.Selector, exportOptions.Exclude)
 	if err != nil {
 		return err
 	}
 
+	if len(exportOptions.OutputDir) > 0 {
+		return exportIntoDir(filter, exportOptions)
+	}
+
 	out, err := openshift.ExportAsTemplateFile(filter, exportOptions)
 	if err != nil {
 		return fmt.Errorf(
@@ -26,3 +36,91 @@ func Export(exportOptions *cli.ExportOptions) error {
 	fmt.Println(out)
 	return nil
 }
+
+func exportIntoDir(filter *openshift.ResourceFilter, exportOptions *cli.ExportOptions) error {
+	_, items, err := openshift.ExportAsTemplate(filter, exportOptions.Namespace, exportOptions.StripDefaults)
+	if err != nil {
+		return fmt.Errorf(
+			"Could not export %s resources as template: %s",
+			filter.String(),
+			err,
+		)
+	}
+
+	templates, err := openshift.SplitExportedItems(items, exportOptions.SplitBy, exportOptions.SplitLabel)
+	if err != nil {
+		return err
+	}
+
+	if exportOptions.Parameterize {
+		rules, err := openshift.ParseParamRules(exportOptions.ParamRules)
+		if err != nil {
+			return err
+		}
+		for _, t := range templates {
+			t.Parameterize(exportOptions.Namespace, rules)
+		}
+	}
+
+	if exportOptions.EncryptSecrets {
+		for _, t := range templates {
+			t.ExtractSecrets()
+		}
+	}
+
+	err = os.MkdirAll(exportOptions.OutputDir, 0755)
+	if err != nil {
+		return fmt.Errorf("Could not create output directory: %s", err)
+	}
+
+	for _, t := range templates {
+		b, err := yaml.Marshal(t.Config())
+		if err != nil {
+			return fmt.Errorf("Could not marshal template %s: %s", t.Filename, err)
+		}
+		filename := exportOptions.OutputDir + string(os.PathSeparator) + t.Filename
+		cli.DebugMsg("Writing template", filename)
+		err = ioutil.WriteFile(filename, b, 0644)
+		if err != nil {
+			return fmt.Errorf("Could not write template: %s", err)
+		}
+		fmt.Printf("Exported %d resource(s) into %s\n", len(t.Items), filename)
+
+		if t.HasParamFile() {
+			paramFilename := exportOptions.OutputDir + string(os.PathSeparator) + t.ParamFilename()
+			cli.DebugMsg("Writing param file", paramFilename)
+			err = ioutil.WriteFile(paramFilename, []byte(t.ParamFileContent()), 0644)
+			if err != nil {
+				return fmt.Errorf("Could not write param file: %s", err)
+			}
+		}
+
+		secretContent := t.SecretParamFileContent()
+		if len(secretContent) > 0 {
+			encFilename := exportOptions.OutputDir + string(os.PathSeparator) + t.ParamFilename() + ".enc"
+			cli.DebugMsg("Writing encrypted param file", encFilename)
+			// Pass previous content so that unchanged values keep their
+			// ciphertext, which keeps repeated exports reviewable.
+			previousContent, _ := utils.ReadFile(encFilename)
+			err = writeEncryptedContent(
+				encFilename,
+				secretContent,
+				previousContent,
+				exportOptions.PrivateKey,
+				exportOptions.Passphrase,
+				exportOptions.PublicKeyDir,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	indexFilename := exportOptions.OutputDir + string(os.PathSeparator) + "index.md"
+	err = ioutil.WriteFile(indexFilename, []byte(openshift.ExportIndex(templates)), 0644)
+	if err != nil {
+		return fmt.Errorf("Could not write index: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/openshift"
+)
+
+// namespaceResult is the outcome of comparing (and possibly updating) one
+// of several namespaces.
+type namespaceResult struct {
+	namespace      string
+	changeset      *openshift.Changeset
+	updateRequired bool
+	applied        bool
+	skipped        bool
+	err            error
+}
+
+// StatusNamespaces runs Status for each of the given namespace options,
+// printing a section per namespace and an aggregated summary. If comparing
+// a namespace fails, the remaining namespaces are still compared.
+func StatusNamespaces(optionsList []*cli.CompareOptions) (bool, error) {
+	results := []*namespaceResult{}
+	for _, compareOptions := range optionsList {
+		printNamespaceHeader(compareOptions.Namespace)
+		updateRequired, changeset, err := Status(compareOptions)
+		if err != nil {
+			cli.PrintRedf("%s\n\n", err)
+		}
+		results = append(results, &namespaceResult{
+			namespace:      compareOptions.Namespace,
+			changeset:      changeset,
+			updateRequired: updateRequired,
+			err:            err,
+		})
+	}
+	printAggregatedSummary(results, false)
+	return aggregatedOutcome(results)
+}
+
+// UpdateNamespaces runs Update for each of the given namespace options,
+// printing a section per namespace and an aggregated summary. If updating a
+// namespace fails, the remaining namespaces are skipped.
+func UpdateNamespaces(optionsList []*cli.CompareOptions) error {
+	results := []*namespaceResult{}
+	var failed bool
+	for _, compareOptions := range optionsList {
+		if failed {
+			results = append(results, &namespaceResult{
+				namespace: compareOptions.Namespace,
+				changeset: &openshift.Changeset{},
+				skipped:   true,
+			})
+			continue
+		}
+		printNamespaceHeader(compareOptions.Namespace)
+		changeset, applied, err := update(compareOptions)
+		if err != nil {
+			cli.PrintRedf("%s\n\n", err)
+			failed = true
+		}
+		results = append(results, &namespaceResult{
+			namespace: compareOptions.Namespace,
+			changeset: changeset,
+			applied:   applied,
+			err:       err,
+		})
+	}
+	printAggregatedSummary(results, true)
+	_, err := aggregatedOutcome(results)
+	return err
+}
+
+// ParamsNamespaces runs Params for each of the given namespace options,
+// printing a section per namespace.
+func ParamsNamespaces(optionsList []*cli.CompareOptions) (bool, error) {
+	problemsFound := false
+	for _, compareOptions := range optionsList {
+		printNamespaceHeader(compareOptions.Namespace)
+		p, err := Params(compareOptions)
+		if err != nil {
+			return problemsFound, err
+		}
+		problemsFound = problemsFound || p
+		fmt.Println("")
+	}
+	return problemsFound, nil
+}
+
+func printNamespaceHeader(namespace string) {
+	title := "Namespace " + namespace
+	fmt.Printf("%s\n%s\n\n", title, strings.Repeat("=", len(title)))
+}
+
+// printAggregatedSummary prints one line per namespace and the total of all
+// changes. If showApplied is true, it is shown whether changes have been
+// applied.
+func printAggregatedSummary(results []*namespaceResult, showApplied bool) {
+	fmt.Printf("Summary of %d namespaces:\n", len(results))
+	total := &openshift.Changeset{}
+	for _, r := range results {
+		fmt.Printf("%s: ", r.namespace)
+		if r.skipped {
+			fmt.Printf("skipped\n")
+			continue
+		}
+		if r.err != nil {
+			cli.PrintRedf("failed\n")
+			continue
+		}
+		printChangesetCounts(r.changeset)
+		if showApplied && !r.changeset.Blank() {
+			if r.applied {
+				fmt.Printf(" (applied)")
+			} else {
+				fmt.Printf(" (not applied)")
+			}
+		}
+		fmt.Printf("\n")
+		total.Noop = append(total.Noop, r.changeset.Noop...)
+		total.Create = append(total.Create, r.changeset.Create...)
+		total.Update = append(total.Update, r.changeset.Update...)
+		total.Delete = append(total.Delete, r.changeset.Delete...)
+	}
+	fmt.Printf("Total: ")
+	printChangesetCounts(total)
+	fmt.Printf("\n\n")
+}
+
+func printChangesetCounts(changeset *openshift.Changeset) {
+	fmt.Printf("%d in sync, ", len(changeset.Noop))
+	cli.PrintGreenf("%d to create", len(changeset.Create))
+	fmt.Printf(", ")
+	cli.PrintYellowf("%d to update", len(changeset.Update))
+	fmt.Printf(", ")
+	cli.PrintRedf("%d to delete", len(changeset.Delete))
+}
+
+// aggregatedOutcome returns whether any namespace has drift, and an error
+// naming the namespaces which failed.
+func aggregatedOutcome(results []*namespaceResult) (bool, error) {
+	updateRequired := false
+	failed := []string{}
+	for _, r := range results {
+		if r.err != nil {
+			failed = append(failed, r.namespace)
+		} else if !r.skipped && (r.updateRequired || !r.changeset.Blank()) {
+			updateRequired = true
+		}
+	}
+	if len(failed) > 0 {
+		return updateRequired, errors.New("Failed namespaces: " + strings.Join(failed, ", "))
+	}
+	return updateRequired, nil
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/opendevstack/tailor/openshift"
+)
+
+func TestAggregatedOutcome(t *testing.T) {
+	inSync := &openshift.Changeset{Noop: []*openshift.Change{&openshift.Change{}}}
+	drift := &openshift.Changeset{Create: []*openshift.Change{&openshift.Change{}}}
+
+	tests := map[string]struct {
+		results                []*namespaceResult
+		expectedUpdateRequired bool
+		expectedError          string
+	}{
+		"all in sync": {
+			results: []*namespaceResult{
+				&namespaceResult{namespace: "foo-dev", changeset: inSync},
+				&namespaceResult{namespace: "foo-test", changeset: inSync},
+			},
+			expectedUpdateRequired: false,
+		},
+		"drift in one namespace": {
+			results: []*namespaceResult{
+				&namespaceResult{namespace: "foo-dev", changeset: inSync},
+				&namespaceResult{namespace: "foo-test", changeset: drift},
+			},
+			expectedUpdateRequired: true,
+		},
+		"missing namespace": {
+			results: []*namespaceResult{
+				&namespaceResult{namespace: "foo-dev", changeset: &openshift.Changeset{}, updateRequired: true},
+			},
+			expectedUpdateRequired: true,
+		},
+		"skipped namespace": {
+			results: []*namespaceResult{
+				&namespaceResult{namespace: "foo-dev", changeset: inSync},
+				&namespaceResult{namespace: "foo-test", changeset: drift, skipped: true},
+			},
+			expectedUpdateRequired: false,
+		},
+		"failed namespaces": {
+			results: []*namespaceResult{
+				&namespaceResult{namespace: "foo-dev", changeset: drift},
+				&namespaceResult{namespace: "foo-test", changeset: &openshift.Changeset{}, err: os.ErrNotExist},
+				&namespaceResult{namespace: "foo-prod", changeset: &openshift.Changeset{}, err: os.ErrPermission},
+			},
+			expectedUpdateRequired: true,
+			expectedError:          "Failed namespaces: foo-test, foo-prod",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			updateRequired, err := aggregatedOutcome(tc.results)
+			if updateRequired != tc.expectedUpdateRequired {
+				t.Errorf("Got update required %t, want %t", updateRequired, tc.expectedUpdateRequired)
+			}
+			if len(tc.expectedError) > 0 {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Errorf("Got error %v, want %s", err, tc.expectedError)
+				}
+			} else if err != nil {
+				t.Errorf("Got error %s", err)
+			}
+		})
+	}
+}
+
+func TestPrintAggregatedSummary(t *testing.T) {
+	results := []*namespaceResult{
+		&namespaceResult{
+			namespace: "foo-dev",
+			changeset: &openshift.Changeset{
+				Noop:   []*openshift.Change{&openshift.Change{}, &openshift.Change{}},
+				Update: []*openshift.Change{&openshift.Change{}},
+			},
+			applied: true,
+		},
+		&namespaceResult{
+			namespace: "foo-test",
+			changeset: &openshift.Changeset{Noop: []*openshift.Change{&openshift.Change{}}},
+		},
+		&namespaceResult{namespace: "foo-prod", changeset: &openshift.Changeset{}, skipped: true},
+	}
+
+	output := captureStdout(t, func() {
+		printAggregatedSummary(results, true)
+	})
+
+	expected := []string{
+		"Summary of 3 namespaces:\n",
+		"foo-dev: 2 in sync, ",
+		" (applied)\n",
+		"foo-test: 1 in sync, ",
+		"foo-prod: skipped\n",
+		"Total: 3 in sync, ",
+	}
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Errorf("Expected output to contain %q, got:\n%s", e, output)
+		}
+	}
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
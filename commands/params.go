@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/openshift"
+	"github.com/opendevstack/tailor/utils"
+)
+
+// Params lists the parameters declared by each template together with how
+// their value is determined, and checks the param files of all layers
+// (including encrypted ones) for missing, unused, duplicated and conflicting
+// keys. It returns true if any problems were found.
+func Params(compareOptions *cli.CompareOptions) (bool, error) {
+	externalKeys := []string{}
+	for _, p := range compareOptions.Params {
+		externalKeys = append(externalKeys, strings.SplitN(p, "=", 2)[0])
+	}
+
+	// Param files given via --param-file are shared by all templates, so
+	// their keys can only be reported as unused if no template declares them.
+	sharedKeys := []string{}
+	if len(compareOptions.ParamFiles) > 0 {
+		sharedLayers, err := openshift.ReadParamLayers("", "", compareOptions, false)
+		if err != nil {
+			return false, err
+		}
+		// The last layer is the command line, which is not a param file.
+		sharedKeys = layeredFileKeys(sharedLayers[:len(sharedLayers)-1], nil)
+	}
+	allDeclared := []*openshift.TemplateParameter{}
+
+	problemsFound := false
+	re := regexp.MustCompile(".*\\.ya?ml$")
+	for i, templateDir := range compareOptions.TemplateDirs {
+		files, err := ioutil.ReadDir(templateDir)
+		if err != nil {
+			return false, err
+		}
+		for _, file := range files {
+			if !re.MatchString(file.Name()) {
+				continue
+			}
+			if len(compareOptions.Templates) > 0 && !utils.Includes(compareOptions.Templates, file.Name()) {
+				continue
+			}
+			filename := templateDir + string(os.PathSeparator) + file.Name()
+			parameters, err := openshift.ReadTemplateParameters(filename)
+			if err != nil {
+				return false, fmt.Errorf("Could not read parameters of %s: %s", filename, err)
+			}
+			allDeclared = append(allDeclared, parameters...)
+
+			layers, err := openshift.ReadParamLayers(file.Name(), compareOptions.ParamDirs[i], compareOptions, false)
+			if err != nil {
+				return false, err
+			}
+			// The last layer is the command line, which is not a param file.
+			fileLayers := layers[:len(layers)-1]
+
+			var check *openshift.ParamCheck
+			if len(compareOptions.ParamFiles) > 0 {
+				keys := append(append([]string{}, sharedKeys...), externalKeys...)
+				check = openshift.CheckParams(parameters, []string{}, keys)
+			} else {
+				check = openshift.CheckParams(parameters, layeredFileKeys(fileLayers, parameters), externalKeys)
+			}
+
+			fmt.Printf("%s (%s)\n", filename, layerSources(fileLayers))
+			if len(parameters) == 0 {
+				fmt.Println("  No parameters declared.")
+			}
+			for _, p := range parameters {
+				fmt.Printf("  %s: %s\n", p.Name, p.Status())
+			}
+			if !printParamCheck(check) {
+				problemsFound = true
+			}
+			if conflicts := layers.Conflicts(); len(conflicts) > 0 {
+				cli.PrintRedf("  Conflicting definitions:\n")
+				for _, c := range conflicts {
+					cli.PrintRedf("  * %s\n", c)
+				}
+				problemsFound = true
+			}
+			fmt.Println("")
+			for _, explainParam := range compareOptions.ExplainParams {
+				openshift.PrintParamExplanation(explainParam, filename, layers, parameters)
+			}
+		}
+	}
+
+	if len(compareOptions.ParamFiles) > 0 {
+		check := openshift.CheckParams(allDeclared, sharedKeys, []string{})
+		check.Missing = []string{}
+		fmt.Printf("Param files %s\n", strings.Join(compareOptions.ParamFiles, ", "))
+		if !printParamCheck(check) {
+			problemsFound = true
+		}
+		fmt.Println("")
+	}
+
+	if problemsFound {
+		cli.PrintRedf("Problems found in param files.\n")
+	} else {
+		cli.PrintGreenf("No problems found in param files.\n")
+	}
+	return problemsFound, nil
+}
+
+// printParamCheck prints the problems of check, and returns true if there
+// are none.
+func printParamCheck(check *openshift.ParamCheck) bool {
+	if len(check.Missing) > 0 {
+		cli.PrintRedf("  Missing values: %s\n", strings.Join(check.Missing, ", "))
+	}
+	if len(check.Unused) > 0 {
+		cli.PrintYellowf("  Unused keys: %s\n", strings.Join(check.Unused, ", "))
+	}
+	if len(check.Duplicated) > 0 {
+		cli.PrintYellowf("  Duplicated keys: %s\n", strings.Join(check.Duplicated, ", "))
+	}
+	return check.OK()
+}
+
+// layeredFileKeys returns the keys of the param files of all layers. A key
+// defined in several layers is returned once, as overriding a value of a
+// lower layer is expected. A key defined several times within one layer is
+// returned as often, so that it is reported as duplicated. Keys of layers
+// which only apply to declared parameters are skipped if not declared.
+func layeredFileKeys(layers openshift.ParamLayers, parameters []*openshift.TemplateParameter) []string {
+	declared := []string{}
+	for _, p := range parameters {
+		declared = append(declared, p.Name)
+	}
+	counts := map[string]int{}
+	keys := []string{}
+	for _, l := range layers {
+		layerCounts := map[string]int{}
+		for _, k := range l.Keys() {
+			if l.DeclaredOnly && !utils.Includes(declared, k) {
+				continue
+			}
+			layerCounts[k]++
+			if layerCounts[k] > counts[k] {
+				counts[k] = layerCounts[k]
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+func layerSources(layers openshift.ParamLayers) string {
+	sources := []string{}
+	for _, l := range layers {
+		sources = append(sources, l.Sources()...)
+	}
+	if len(sources) == 0 {
+		return "no param file"
+	}
+	return strings.Join(sources, ", ")
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opendevstack/tailor/cli"
+)
+
+func TestParamsUsesNamespaceOfOcContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailor-params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	template, err := ioutil.ReadFile("../testdata/template-with-params.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "foo.yml"), template, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TAILOR_TEST_NAMESPACE", "foo-dev")
+	defer os.Unsetenv("TAILOR_TEST_NAMESPACE")
+
+	compareOptions := &cli.CompareOptions{
+		GlobalOptions: &cli.GlobalOptions{
+			OcBinary:     fakeOcBinary,
+			TemplateDirs: []string{dir},
+			ParamDirs:    []string{"../testdata/layered-params"},
+		},
+		Diff:          "text",
+		ExplainParams: []string{"DB_HOST"},
+	}
+	err = compareOptions.GlobalOptions.Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = compareOptions.ProcessWithoutCluster()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compareOptions.Namespace != "foo-dev" {
+		t.Fatalf("Got namespace %q, want foo-dev", compareOptions.Namespace)
+	}
+
+	output := captureStdout(t, func() {
+		_, err = Params(compareOptions)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `namespace (../testdata/layered-params/foo-dev/foo.env): "db.dev" <- final value`
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected namespace layer to override template layer, got:\n%s", output)
+	}
+}
+
+func TestParamsRejectsParamDirWithParamFile(t *testing.T) {
+	compareOptions := &cli.CompareOptions{
+		GlobalOptions: &cli.GlobalOptions{
+			OcBinary:  fakeOcBinary,
+			Namespace: "foo-dev",
+			ParamDirs: []string{"../testdata/layered-params"},
+		},
+		Diff:       "text",
+		ParamFiles: []string{"../testdata/layered-params/foo.env"},
+	}
+	err := compareOptions.ProcessWithoutCluster()
+	if err == nil || !strings.Contains(err.Error(), "You cannot specify both --param-dir and --param-file") {
+		t.Errorf("Expected conflicting param options to be rejected, got: %v", err)
+	}
+}
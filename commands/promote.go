@@ -0,0 +1,371 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/openshift"
+	"github.com/opendevstack/tailor/utils"
+)
+
+// templateParams holds the effective params of one template of a namespace,
+// resolved from all param layers.
+type templateParams struct {
+	template string
+	// paramFile is the param file of the namespace layer, into which
+	// params are copied.
+	paramFile string
+	// params are keyed as written in the param files, e.g. "TOKEN.B64".
+	params    map[string]string
+	encrypted map[string]bool
+	// namespaceKeys lists the keys defined in paramFile.
+	namespaceKeys map[string]bool
+}
+
+// Promote compares the desired state of two namespaces, showing which
+// parameters and template objects differ. Then it copies the requested
+// parameter values from the source to the target param files, and prints
+// the status of the target namespace.
+func Promote(promoteOptions *cli.PromoteOptions) (bool, error) {
+	from := promoteOptions.From
+	to := promoteOptions.To
+
+	fmt.Printf(
+		"Comparing desired state of namespace %s with namespace %s.\n\n",
+		from.Namespace,
+		to.Namespace,
+	)
+
+	fromParams, err := readTemplateParams(from)
+	if err != nil {
+		return false, err
+	}
+	toParams, err := readTemplateParams(to)
+	if err != nil {
+		return false, err
+	}
+	printParamDifferences(fromParams, toParams, from.Namespace, to.Namespace)
+
+	err = printObjectDifferences(from, to)
+	if err != nil {
+		return false, err
+	}
+
+	if len(promoteOptions.CopyParams) > 0 {
+		err = copyParams(promoteOptions.CopyParams, fromParams, toParams, to)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	updateRequired, _, err := calculateChangeset(to)
+	return updateRequired, err
+}
+
+// readTemplateParams reads the effective params of each template of the
+// namespace. Encrypted params are decrypted.
+func readTemplateParams(compareOptions *cli.CompareOptions) ([]*templateParams, error) {
+	all := []*templateParams{}
+	re := regexp.MustCompile(".*\\.ya?ml$")
+	for i, templateDir := range compareOptions.TemplateDirs {
+		files, err := ioutil.ReadDir(templateDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if !re.MatchString(file.Name()) {
+				continue
+			}
+			if len(compareOptions.Templates) > 0 && !utils.Includes(compareOptions.Templates, file.Name()) {
+				continue
+			}
+			filename := templateDir + string(os.PathSeparator) + file.Name()
+			parameters, err := openshift.ReadTemplateParameters(filename)
+			if err != nil {
+				return nil, fmt.Errorf("Could not read parameters of %s: %s", filename, err)
+			}
+			declared := []string{}
+			for _, p := range parameters {
+				declared = append(declared, p.Name)
+			}
+			paramDir := compareOptions.ParamDirs[i]
+			layers, err := openshift.ReadParamLayers(file.Name(), paramDir, compareOptions, true)
+			if err != nil {
+				return nil, err
+			}
+			tp := &templateParams{
+				template:      file.Name(),
+				paramFile:     openshift.NamespaceParamFilename(file.Name(), paramDir, compareOptions.Namespace),
+				params:        map[string]string{},
+				encrypted:     map[string]bool{},
+				namespaceKeys: map[string]bool{},
+			}
+			// The command line is not part of the desired state stored in
+			// param files, so it is not compared.
+			for _, d := range layers[:len(layers)-1].Resolve(declared) {
+				tp.params[d.Key] = d.Cleartext
+				tp.encrypted[d.Key] = d.Encrypted
+			}
+			for _, l := range layers {
+				for _, d := range l.Definitions {
+					if d.Source == tp.paramFile {
+						tp.namespaceKeys[d.Key] = true
+					}
+				}
+			}
+			all = append(all, tp)
+		}
+	}
+	return all, nil
+}
+
+func findTemplateParams(all []*templateParams, template string) *templateParams {
+	for _, tp := range all {
+		if tp.template == template {
+			return tp
+		}
+	}
+	return nil
+}
+
+func printParamDifferences(fromParams, toParams []*templateParams, fromNamespace, toNamespace string) {
+	fmt.Println("Parameters:")
+	templates := []string{}
+	for _, tp := range append(append([]*templateParams{}, fromParams...), toParams...) {
+		if !utils.Includes(templates, tp.template) {
+			templates = append(templates, tp.template)
+		}
+	}
+	count := 0
+	for _, template := range templates {
+		fromTp := findTemplateParams(fromParams, template)
+		toTp := findTemplateParams(toParams, template)
+		fromValues, toValues := map[string]string{}, map[string]string{}
+		if fromTp != nil {
+			fromValues = fromTp.params
+		}
+		if toTp != nil {
+			toValues = toTp.params
+		}
+		for _, d := range openshift.CompareParams(template, fromValues, toValues) {
+			count++
+			switch {
+			case d.To == nil:
+				cli.PrintGreenf(
+					"+ %s: %s is only set in %s (%s)\n",
+					template, d.Name, fromNamespace, displayParamValue(fromTp, d.Name, *d.From),
+				)
+			case d.From == nil:
+				cli.PrintRedf(
+					"- %s: %s is only set in %s (%s)\n",
+					template, d.Name, toNamespace, displayParamValue(toTp, d.Name, *d.To),
+				)
+			default:
+				cli.PrintYellowf(
+					"~ %s: %s is %s in %s and %s in %s\n",
+					template, d.Name,
+					displayParamValue(fromTp, d.Name, *d.From), fromNamespace,
+					displayParamValue(toTp, d.Name, *d.To), toNamespace,
+				)
+			}
+		}
+	}
+	if count == 0 {
+		fmt.Println("All parameters are set to the same values.")
+	}
+	fmt.Println("")
+}
+
+// displayParamValue returns the quoted value, or a placeholder for
+// encrypted values.
+func displayParamValue(tp *templateParams, name string, value string) string {
+	if tp != nil && tp.encrypted[name] {
+		return "(encrypted)"
+	}
+	return strconv.Quote(value)
+}
+
+func printObjectDifferences(from, to *cli.CompareOptions) error {
+	filter, err := openshift.NewResourceFilter(to.Resource, to.Selector, to.Exclude)
+	if err != nil {
+		return err
+	}
+	fromList, err := assembleTemplateBasedResourceList(filter, from)
+	if err != nil {
+		return err
+	}
+	toList, err := assembleTemplateBasedResourceList(filter, to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nTemplate objects:")
+	changes := openshift.CompareDesiredStates(fromList, from.Namespace, toList, to.Namespace)
+	for _, change := range changes {
+		switch change.Action {
+		case "Create":
+			cli.PrintGreenf("+ %s is only defined for %s\n", change.ItemNameWithOrigin(), from.Namespace)
+		case "Delete":
+			cli.PrintRedf("- %s is only defined for %s\n", change.ItemNameWithOrigin(), to.Namespace)
+		default:
+			cli.PrintYellowf("~ %s differs\n", change.ItemNameWithOrigin())
+			if change.Kind == "Secret" {
+				fmt.Println("  (secret data not shown)")
+			} else {
+				fmt.Print(change.PromotionDiff(from.Namespace, to.Namespace))
+			}
+		}
+	}
+	if len(changes) == 0 {
+		fmt.Println("All template objects are the same.")
+	}
+	fmt.Println("")
+	return nil
+}
+
+// copyParams copies the values of given params from the source param files
+// into the target param files of the same template. Values which are
+// encrypted in the source are written into the encrypted param file of the
+// target, and thereby encrypted for the public keys of the target.
+func copyParams(names []string, fromParams, toParams []*templateParams, to *cli.CompareOptions) error {
+	type paramCopy struct {
+		toTp      *templateParams
+		plain     map[string]string
+		encrypted map[string]string
+		// removePlain lists keys which move from the param file into the
+		// encrypted param file of the target.
+		removePlain []string
+	}
+	copies := []*paramCopy{}
+	found := map[string]bool{}
+	for _, fromTp := range fromParams {
+		toTp := findTemplateParams(toParams, fromTp.template)
+		if toTp == nil {
+			continue
+		}
+		c := &paramCopy{toTp: toTp, plain: map[string]string{}, encrypted: map[string]string{}}
+		for _, name := range names {
+			for key, value := range fromTp.params {
+				if key != name && key != name+".B64" {
+					continue
+				}
+				found[name] = true
+				if toTp.params[key] == value {
+					continue
+				}
+				if fromTp.encrypted[key] || toTp.encrypted[key] {
+					c.encrypted[key] = value
+					if toTp.namespaceKeys[key] {
+						c.removePlain = append(c.removePlain, key)
+					}
+				} else {
+					c.plain[key] = value
+				}
+			}
+		}
+		if len(c.plain) > 0 || len(c.encrypted) > 0 {
+			copies = append(copies, c)
+		}
+	}
+	missing := []string{}
+	for _, name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf(
+			"Parameters %s are not set for any template shared by both namespaces",
+			strings.Join(missing, ", "),
+		)
+	}
+	if len(copies) == 0 {
+		fmt.Printf("Parameters %s are already set to the same values.\n\n", strings.Join(names, ", "))
+		return nil
+	}
+
+	fmt.Printf("Copying parameters into namespace %s:\n", to.Namespace)
+	for _, c := range copies {
+		for _, key := range sortedParamKeys(c.plain) {
+			fmt.Printf("* %s: %s\n", c.toTp.paramFile, key)
+		}
+		for _, key := range sortedParamKeys(c.encrypted) {
+			fmt.Printf("* %s.enc: %s\n", c.toTp.paramFile, key)
+		}
+	}
+	if !to.NonInteractive {
+		if !cli.AskForConfirmation("Write param files?") {
+			fmt.Println("")
+			return nil
+		}
+	}
+
+	for _, c := range copies {
+		err := os.MkdirAll(filepath.Dir(c.toTp.paramFile), 0755)
+		if err != nil {
+			return fmt.Errorf("Could not create directory: %s", err)
+		}
+		if len(c.plain) > 0 || len(c.removePlain) > 0 {
+			content, err := utils.ReadFile(c.toTp.paramFile)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("Could not read file: %s", err)
+			}
+			newContent, err := openshift.SetParams(content, c.plain)
+			if err != nil {
+				return err
+			}
+			newContent, err = openshift.RemoveParams(newContent, c.removePlain)
+			if err != nil {
+				return err
+			}
+			err = ioutil.WriteFile(c.toTp.paramFile, []byte(newContent), 0644)
+			if err != nil {
+				return fmt.Errorf("Could not write file: %s", err)
+			}
+		}
+		if len(c.encrypted) > 0 {
+			encFilename := c.toTp.paramFile + ".enc"
+			encryptedContent, err := utils.ReadFile(encFilename)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("Could not read file: %s", err)
+			}
+			cleartextContent, err := openshift.DecryptedParams(encryptedContent, to.PrivateKey, to.Passphrase)
+			if err != nil {
+				return fmt.Errorf("Could not decrypt file: %s", err)
+			}
+			newContent, err := openshift.SetParams(cleartextContent, c.encrypted)
+			if err != nil {
+				return err
+			}
+			err = writeEncryptedContent(
+				encFilename,
+				newContent,
+				encryptedContent,
+				to.PrivateKey,
+				to.Passphrase,
+				to.PublicKeyDir,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Println("")
+	return nil
+}
+
+func sortedParamKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
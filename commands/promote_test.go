@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/openshift"
+	"github.com/opendevstack/tailor/utils"
+)
+
+func TestReadTemplateParams(t *testing.T) {
+	templateDir, err := ioutil.TempDir("", "tailor-promote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(templateDir)
+	template, err := ioutil.ReadFile("../testdata/template-with-params.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(templateDir, "foo.yml"), template, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compareOptions := &cli.CompareOptions{
+		GlobalOptions: &cli.GlobalOptions{
+			Namespace:    "foo-dev",
+			TemplateDirs: []string{templateDir},
+			ParamDirs:    []string{"../testdata/layered-params"},
+		},
+		Params: []string{"REPLICAS=5"},
+	}
+	all, err := readTemplateParams(compareOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("Expected params of one template, got %d", len(all))
+	}
+	tp := all[0]
+	if tp.paramFile != "../testdata/layered-params/foo-dev/foo.env" {
+		t.Errorf("Params should be copied into the namespace layer, got %s", tp.paramFile)
+	}
+	expected := map[string]string{"DB_HOST": "db.dev", "MEMORY": "2Gi", "REPLICAS": "2"}
+	if len(tp.params) != len(expected) {
+		t.Errorf("Got params %v, want %v", tp.params, expected)
+	}
+	for k, v := range expected {
+		if tp.params[k] != v {
+			t.Errorf("Got %s=%s, want %s", k, tp.params[k], v)
+		}
+	}
+	if !tp.namespaceKeys["DB_HOST"] || tp.namespaceKeys["REPLICAS"] {
+		t.Errorf("Got namespace keys %v", tp.namespaceKeys)
+	}
+}
+
+func TestCopyParams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailor-promote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	publicKeyDir := filepath.Join(dir, "public-keys")
+	err = os.Mkdir(publicKeyDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := ioutil.ReadFile("../openshift/test-public.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(publicKeyDir, "test.key"), publicKey, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paramFile := filepath.Join(dir, "foo.env")
+	err = ioutil.WriteFile(paramFile, []byte("DB_HOST=db\nDB_PASSWORD=old\nREPLICAS=1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromParams := []*templateParams{
+		&templateParams{
+			template:  "foo.yml",
+			params:    map[string]string{"DB_PASSWORD": "secret", "REPLICAS": "3"},
+			encrypted: map[string]bool{"DB_PASSWORD": true},
+		},
+	}
+	toParams := []*templateParams{
+		&templateParams{
+			template:  "foo.yml",
+			paramFile: paramFile,
+			params:    map[string]string{"DB_HOST": "db", "DB_PASSWORD": "old", "REPLICAS": "1"},
+			encrypted: map[string]bool{},
+			namespaceKeys: map[string]bool{
+				"DB_HOST":     true,
+				"DB_PASSWORD": true,
+				"REPLICAS":    true,
+			},
+		},
+	}
+	to := &cli.CompareOptions{
+		GlobalOptions: &cli.GlobalOptions{
+			Namespace:      "foo-prod",
+			NonInteractive: true,
+			PrivateKey:     "../openshift/test-private.key",
+			PublicKeyDir:   publicKeyDir,
+		},
+	}
+
+	err = copyParams([]string{"DB_PASSWORD", "REPLICAS"}, fromParams, toParams, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := utils.ReadFile(paramFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPlain := "DB_HOST=db\nREPLICAS=3\n"
+	if plain != expectedPlain {
+		t.Errorf("Got param file:\n%s\nwant:\n%s", plain, expectedPlain)
+	}
+
+	encrypted, err := utils.ReadFile(paramFile + ".enc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := openshift.DecryptedParams(encrypted, to.PrivateKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedDecrypted := "DB_PASSWORD=secret\n"
+	if decrypted != expectedDecrypted {
+		t.Errorf("Got encrypted param file:\n%s\nwant:\n%s", decrypted, expectedDecrypted)
+	}
+}
@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/openshift"
+)
+
+// LoadRules adds the rules of the rules file (if any) to the built-in rules.
+func LoadRules(globalOptions *cli.GlobalOptions) error {
+	if len(globalOptions.RulesFile) == 0 {
+		return nil
+	}
+	cli.DebugMsg("Loading rules from", globalOptions.RulesFile)
+	return openshift.LoadFieldRules(globalOptions.RulesFile)
+}
+
+// Rules prints all active field rules to STDOUT.
+func Rules() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tACTION\tPATH / PATTERN\tSOURCE")
+	for _, r := range openshift.FieldRules() {
+		kind := r.Kind
+		if len(kind) == 0 {
+			kind = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", kind, r.Action, r.Target(), r.Source)
+	}
+	w.Flush()
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/opendevstack/tailor/cli"
 	"github.com/opendevstack/tailor/openshift"
+	"github.com/opendevstack/tailor/utils"
 )
 
 // Status prints the drift between desired and current state to STDOUT.
@@ -30,6 +31,13 @@ func calculateChangeset(compareOptions *cli.CompareOptions) (bool, *openshift.Ch
 		compareOptions.Namespace,
 	)
 
+	if compareOptions.NamespaceMissing {
+		cli.PrintYellowf(
+			"Namespace %s does not exist yet and will be created.\n",
+			compareOptions.Namespace,
+		)
+	}
+
 	if len(compareOptions.Resource) > 0 && len(compareOptions.Selector) > 0 {
 		fmt.Printf(
 			"Limiting resources to %s with selector %s.\n",
@@ -63,11 +71,28 @@ func calculateChangeset(compareOptions *cli.CompareOptions) (bool, *openshift.Ch
 		return updateRequired, &openshift.Changeset{}, err
 	}
 
-	platformBasedList, err := assemblePlatformBasedResourceList(filter, compareOptions)
+	clusterScopedItems := templateBasedList.ClusterScopedItems()
+	if len(clusterScopedItems) > 0 && !compareOptions.ClusterScoped {
+		names := []string{}
+		for _, item := range clusterScopedItems {
+			names = append(names, item.FullName())
+		}
+		return updateRequired, &openshift.Changeset{}, fmt.Errorf(
+			"Templates contain cluster-scoped resources (%s). Pass --cluster-scoped to manage them",
+			strings.Join(names, ", "),
+		)
+	}
+
+	platformBasedList, err := assemblePlatformBasedResourceList(filter, compareOptions, clusterScopedItems)
 	if err != nil {
 		return updateRequired, &openshift.Changeset{}, err
 	}
 
+	if compareOptions.IgnoreDefaults {
+		templateBasedList.RemoveServerDefaults()
+		platformBasedList.RemoveServerDefaults()
+	}
+
 	platformResourcesWord := "resources"
 	if platformBasedList.Length() == 1 {
 		platformResourcesWord = "resource"
@@ -84,7 +109,9 @@ func calculateChangeset(compareOptions *cli.CompareOptions) (bool, *openshift.Ch
 		templateResourcesWord,
 	)
 
-	if templateBasedList.Length() == 0 && !compareOptions.Force {
+	// Nothing can be deleted from a missing namespace, so an empty desired
+	// state is fine then.
+	if templateBasedList.Length() == 0 && !compareOptions.Force && !compareOptions.NamespaceMissing {
 		fmt.Printf("No items where found in desired state. ")
 		if len(compareOptions.Resource) == 0 && len(compareOptions.Selector) == 0 {
 			fmt.Printf(
@@ -120,15 +147,18 @@ func calculateChangeset(compareOptions *cli.CompareOptions) (bool, *openshift.Ch
 		compareOptions.UpsertOnly,
 		compareOptions.Diff,
 		compareOptions.IgnorePaths,
+		compareOptions.RevealSecrets,
 	)
 	if err != nil {
 		return false, changeset, err
 	}
-	updateRequired = !changeset.Blank()
+	// A missing namespace needs to be created even if there are no
+	// resources to create in it.
+	updateRequired = !changeset.Blank() || compareOptions.NamespaceMissing
 	return updateRequired, changeset, nil
 }
 
-func compare(remoteResourceList *openshift.ResourceList, localResourceList *openshift.ResourceList, upsertOnly bool, diff string, ignorePaths []string) (*openshift.Changeset, error) {
+func compare(remoteResourceList *openshift.ResourceList, localResourceList *openshift.ResourceList, upsertOnly bool, diff string, ignorePaths []string, revealSecrets bool) (*openshift.Changeset, error) {
 	changeset, err := openshift.NewChangeset(remoteResourceList, localResourceList, upsertOnly, ignorePaths)
 	if err != nil {
 		return changeset, err
@@ -139,38 +169,98 @@ func compare(remoteResourceList *openshift.ResourceList, localResourceList *open
 	}
 
 	for _, change := range changeset.Delete {
+		if changeset.IsRenamed(change) {
+			continue
+		}
 		cli.PrintRedf("- %s to delete\n", change.ItemName())
-		fmt.Print(change.Diff())
+		if change.Kind == "Secret" {
+			fmt.Print(change.SecretDiff(revealSecrets))
+		} else {
+			fmt.Print(change.Diff())
+		}
 	}
 
 	for _, change := range changeset.Create {
-		cli.PrintGreenf("+ %s to create\n", change.ItemName())
-		fmt.Print(change.Diff())
+		if changeset.IsRenamed(change) {
+			continue
+		}
+		cli.PrintGreenf("+ %s to create\n", change.ItemNameWithOrigin())
+		if change.Kind == "Secret" {
+			fmt.Print(change.SecretDiff(revealSecrets))
+		} else {
+			fmt.Print(change.Diff())
+		}
 	}
 
+	for _, rename := range changeset.Rename {
+		cli.PrintYellowf("~ %s renamed (%.0f%% similar)\n", rename.ItemNameWithOrigin(), rename.Similarity*100)
+		if rename.Kind == "Secret" {
+			fmt.Print(rename.SecretDiff(revealSecrets))
+		} else {
+			fmt.Print(rename.Diff())
+		}
+		if rename.Stateful() {
+			cli.PrintRedf(
+				"WARNING: Renaming %s %s deletes it and all its data. Migrate the data to %s before applying this change with --force.\n",
+				rename.Kind,
+				rename.From,
+				rename.To,
+			)
+		}
+	}
+
+	terminalWidth := 0
+	if diff == "side-by-side" && len(changeset.Update) > 0 {
+		terminalWidth = cli.TerminalWidth()
+	}
 	for _, change := range changeset.Update {
-		cli.PrintYellowf("~ %s to update\n", change.ItemName())
-		if diff == "text" {
+		cli.PrintYellowf("~ %s to update\n", change.ItemNameWithOrigin())
+		if diff == "json" {
+			fmt.Println(change.JsonReport(true, revealSecrets))
+			continue
+		}
+		if change.Kind == "Secret" {
+			fmt.Print(change.SecretDiff(revealSecrets))
+			continue
+		}
+		switch diff {
+		case "text":
 			fmt.Print(change.Diff())
-		} else {
-			fmt.Println(change.JsonPatches(true))
+		case "structural":
+			fmt.Print(change.StructuralDiff())
+		case "word":
+			fmt.Print(change.WordDiff(false, 0))
+		case "side-by-side":
+			fmt.Print(change.WordDiff(true, terminalWidth))
 		}
 	}
 
+	if clusterScopedChanges := changeset.ClusterScopedChanges(); len(clusterScopedChanges) > 0 {
+		cli.PrintRedf("\n%d of the changes affect cluster-scoped resources.\n", len(clusterScopedChanges))
+	}
+
 	fmt.Printf("\nSummary: %d in sync, ", len(changeset.Noop))
 	cli.PrintGreenf("%d to create", len(changeset.Create))
 	fmt.Printf(", ")
 	cli.PrintYellowf("%d to update", len(changeset.Update))
 	fmt.Printf(", ")
-	cli.PrintRedf("%d to delete\n\n", len(changeset.Delete))
+	cli.PrintRedf("%d to delete", len(changeset.Delete))
+	if len(changeset.Rename) > 0 {
+		fmt.Printf(" (incl. %d renamed)", len(changeset.Rename))
+	}
+	fmt.Printf("\n\n")
 
 	return changeset, nil
 }
 
 func assembleTemplateBasedResourceList(filter *openshift.ResourceFilter, compareOptions *cli.CompareOptions) (*openshift.ResourceList, error) {
-	var inputs [][]byte
+	list, err := openshift.NewTemplateBasedResourceList(filter)
+	if err != nil {
+		return nil, err
+	}
 
 	// read files in folders and assemble lists for kinds
+	foundTemplates := map[string]bool{}
 	for i, templateDir := range compareOptions.TemplateDirs {
 		files, err := ioutil.ReadDir(templateDir)
 		if err != nil {
@@ -183,22 +273,55 @@ func assembleTemplateBasedResourceList(filter *openshift.ResourceFilter, compare
 			if !matched {
 				continue
 			}
+			if len(compareOptions.Templates) > 0 {
+				if !utils.Includes(compareOptions.Templates, file.Name()) {
+					cli.DebugMsg("Skipping template", file.Name(), "as it is not listed in templates")
+					continue
+				}
+				foundTemplates[file.Name()] = true
+			}
 			cli.DebugMsg("Reading template", file.Name())
 			processedOut, err := openshift.ProcessTemplate(templateDir, file.Name(), compareOptions.ParamDirs[i], compareOptions)
 			if err != nil {
 				return nil, fmt.Errorf("Could not process %s template: %s", file.Name(), err)
 			}
-			inputs = append(inputs, processedOut)
+			err = list.AppendProcessedTemplate(templateDir+string(os.PathSeparator)+file.Name(), processedOut)
+			if err != nil {
+				return nil, fmt.Errorf("Could not read %s template: %s", file.Name(), err)
+			}
+		}
+	}
+
+	missingTemplates := []string{}
+	for _, t := range compareOptions.Templates {
+		if !foundTemplates[t] {
+			missingTemplates = append(missingTemplates, t)
 		}
 	}
+	if len(missingTemplates) > 0 {
+		return nil, fmt.Errorf(
+			"Templates %s not found in %s",
+			strings.Join(missingTemplates, ", "),
+			strings.Join(compareOptions.TemplateDirs, ", "),
+		)
+	}
 
-	return openshift.NewTemplateBasedResourceList(filter, inputs...)
+	return list, list.CheckDuplicates()
 }
 
-func assemblePlatformBasedResourceList(filter *openshift.ResourceFilter, compareOptions *cli.CompareOptions) (*openshift.ResourceList, error) {
-	exportedOut, err := openshift.ExportResources(filter, compareOptions.Namespace)
+func assemblePlatformBasedResourceList(filter *openshift.ResourceFilter, compareOptions *cli.CompareOptions, clusterScopedItems []*openshift.ResourceItem) (*openshift.ResourceList, error) {
+	// A namespace which does not exist yet has no resources.
+	exportedOut := []byte{}
+	if !compareOptions.NamespaceMissing {
+		out, err := openshift.ExportResources(filter, compareOptions.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("Could not export %s resources", filter.String())
+		}
+		exportedOut = out
+	}
+	clusterScopedOut, err := openshift.ExportClusterScopedResources(clusterScopedItems)
 	if err != nil {
-		return nil, fmt.Errorf("Could not export %s resources", filter.String())
+		return nil, err
 	}
-	return openshift.NewPlatformBasedResourceList(filter, exportedOut)
+	return openshift.NewPlatformBasedResourceList(filter, append([][]byte{exportedOut}, clusterScopedOut...)...)
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/opendevstack/tailor/cli"
 	"github.com/opendevstack/tailor/openshift"
@@ -12,30 +13,87 @@ import (
 // Update prints the drift between desired and current state to STDOUT.
 // If there is any, it asks for confirmation and applies the changeset.
 func Update(compareOptions *cli.CompareOptions) error {
+	_, _, err := update(compareOptions)
+	return err
+}
+
+// update is like Update, but also returns the changeset and whether it has
+// been applied.
+func update(compareOptions *cli.CompareOptions) (*openshift.Changeset, bool, error) {
 	updateRequired, changeset, err := calculateChangeset(compareOptions)
 	if err != nil {
-		return err
+		return changeset, false, err
 	}
 
-	if updateRequired {
-		if compareOptions.NonInteractive {
-			err = apply(compareOptions, changeset)
-			if err != nil {
-				return fmt.Errorf("Update aborted: %s", err)
-			}
-		} else {
-			c := cli.AskForConfirmation("Apply changes?")
-			if c {
-				fmt.Println("")
-				err = apply(compareOptions, changeset)
-				if err != nil {
-					return fmt.Errorf("Update aborted: %s", err)
-				}
-			}
+	if !updateRequired {
+		return changeset, false, nil
+	}
+
+	statefulRenames := changeset.StatefulRenames()
+	if len(statefulRenames) > 0 && !compareOptions.Force {
+		return changeset, false, fmt.Errorf(
+			"Update aborted: Renaming %s deletes data, use --force to apply it anyway",
+			renameNames(statefulRenames),
+		)
+	}
+
+	clusterScopedChanges := changeset.ClusterScopedChanges()
+	if len(clusterScopedChanges) > 0 {
+		err = openshift.CheckClusterPermissions(clusterScopedChanges)
+		if err != nil {
+			return changeset, false, err
+		}
+		// Without a prompt, cluster-wide changes need to be acknowledged
+		// explicitly.
+		if compareOptions.NonInteractive && !compareOptions.Force {
+			return changeset, false, fmt.Errorf(
+				"Update aborted: %d of the changes affect cluster-scoped resources, use --force to apply them in non-interactive mode",
+				len(clusterScopedChanges),
+			)
+		}
+	}
+	if !compareOptions.NonInteractive {
+		c := cli.AskForConfirmation("Apply changes?")
+		if c && len(clusterScopedChanges) > 0 {
+			cli.PrintRedf(
+				"%d of the changes affect cluster-scoped resources, which are not limited to namespace %s.\n",
+				len(clusterScopedChanges),
+				compareOptions.Namespace,
+			)
+			c = cli.AskForConfirmation("Apply cluster-wide changes?")
 		}
+		if c && len(statefulRenames) > 0 {
+			cli.PrintRedf(
+				"Renaming %s deletes the old resources and all their data.\n",
+				renameNames(statefulRenames),
+			)
+			c = cli.AskForConfirmation("Delete the data of renamed resources?")
+		}
+		if !c {
+			return changeset, false, nil
+		}
+		fmt.Println("")
+	}
+	if compareOptions.NamespaceMissing {
+		err = ocNewProject(compareOptions)
+		if err != nil {
+			return changeset, false, fmt.Errorf("Update aborted: %s", err)
+		}
+		compareOptions.NamespaceMissing = false
 	}
+	err = apply(compareOptions, changeset)
+	if err != nil {
+		return changeset, false, fmt.Errorf("Update aborted: %s", err)
+	}
+	return changeset, true, nil
+}
 
-	return nil
+func renameNames(renames []*openshift.Rename) string {
+	names := []string{}
+	for _, r := range renames {
+		names = append(names, r.ItemName())
+	}
+	return strings.Join(names, ", ")
 }
 
 func apply(compareOptions *cli.CompareOptions, c *openshift.Changeset) error {
@@ -63,6 +121,42 @@ func apply(compareOptions *cli.CompareOptions, c *openshift.Changeset) error {
 	return nil
 }
 
+// ocNewProject creates the namespace, setting display name, description,
+// labels and annotations as configured.
+func ocNewProject(compareOptions *cli.CompareOptions) error {
+	namespace := compareOptions.Namespace
+	fmt.Printf("Creating project %s ... ", namespace)
+	args := []string{"new-project", namespace, "--skip-config-write"}
+	if len(compareOptions.NamespaceDisplayName) > 0 {
+		args = append(args, "--display-name="+compareOptions.NamespaceDisplayName)
+	}
+	if len(compareOptions.NamespaceDescription) > 0 {
+		args = append(args, "--description="+compareOptions.NamespaceDescription)
+	}
+	_, errBytes, err := cli.RunCmd(cli.ExecPlainOcCmd(args))
+	if err != nil {
+		fmt.Println("failed")
+		return errors.New(string(errBytes))
+	}
+	for _, verb := range []string{"label", "annotate"} {
+		pairs := compareOptions.NamespaceLabels
+		if verb == "annotate" {
+			pairs = compareOptions.NamespaceAnnotations
+		}
+		if len(pairs) == 0 {
+			continue
+		}
+		args := append([]string{verb, "namespace", namespace, "--overwrite"}, pairs...)
+		_, errBytes, err := cli.RunCmd(cli.ExecPlainOcCmd(args))
+		if err != nil {
+			fmt.Println("failed")
+			return errors.New(string(errBytes))
+		}
+	}
+	fmt.Println("done")
+	return nil
+}
+
 func ocDelete(change *openshift.Change, compareOptions *cli.CompareOptions) error {
 	kind := change.Kind
 	name := change.Name
@@ -70,7 +164,7 @@ func ocDelete(change *openshift.Change, compareOptions *cli.CompareOptions) erro
 	args := []string{"delete", kind, name}
 	cmd := cli.ExecOcCmd(
 		args,
-		compareOptions.Namespace,
+		changeNamespace(change, compareOptions),
 		"", // empty as name and selector is not allowed
 	)
 	_, errBytes, err := cli.RunCmd(cmd)
@@ -91,8 +185,8 @@ func ocCreate(change *openshift.Change, compareOptions *cli.CompareOptions) erro
 	args := []string{"create", "-f", "-"}
 	cmd := cli.ExecOcCmd(
 		args,
-		compareOptions.Namespace,
-		compareOptions.Selector,
+		changeNamespace(change, compareOptions),
+		openshift.LabelSelector(compareOptions.Selector),
 	)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -107,7 +201,7 @@ func ocCreate(change *openshift.Change, compareOptions *cli.CompareOptions) erro
 		fmt.Println("done")
 	} else {
 		fmt.Println("failed")
-		return errors.New(string(errBytes))
+		return applyError(change, errBytes)
 	}
 
 	return nil
@@ -124,7 +218,7 @@ func ocPatch(change *openshift.Change, compareOptions *cli.CompareOptions) error
 	args := []string{"patch", kind + "/" + name, "--type=json", "--patch", j}
 	cmd := cli.ExecOcCmd(
 		args,
-		compareOptions.Namespace,
+		changeNamespace(change, compareOptions),
 		"", // empty as name and selector is not allowed
 	)
 	_, errBytes, err := cli.RunCmd(cmd)
@@ -132,7 +226,25 @@ func ocPatch(change *openshift.Change, compareOptions *cli.CompareOptions) error
 		fmt.Println("done")
 	} else {
 		fmt.Println("failed")
-		return errors.New(string(errBytes))
+		return applyError(change, errBytes)
 	}
 	return nil
 }
+
+// applyError returns the error reported by oc, pointing to the origin of the
+// desired state if known.
+func applyError(change *openshift.Change, errBytes []byte) error {
+	if len(change.Origin) == 0 {
+		return errors.New(string(errBytes))
+	}
+	return fmt.Errorf("%s (defined in %s)", strings.TrimSpace(string(errBytes)), change.Origin)
+}
+
+// changeNamespace returns the namespace to pass to oc for change, which is
+// empty for cluster-scoped resources.
+func changeNamespace(change *openshift.Change, compareOptions *cli.CompareOptions) string {
+	if change.ClusterScoped() {
+		return ""
+	}
+	return compareOptions.Namespace
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opendevstack/tailor/cli"
+)
+
+// fakeOcBinary is a stub of the oc binary, see the script for how to adjust
+// its behaviour.
+const fakeOcBinary = "../testdata/fake-oc/oc"
+
+func TestUpdateCreatesMissingNamespace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailor-update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	templateDir := filepath.Join(dir, "templates")
+	err = os.Mkdir(templateDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFile := filepath.Join(dir, "oc.log")
+	os.Setenv("TAILOR_TEST_OC_LOG", logFile)
+	defer os.Unsetenv("TAILOR_TEST_OC_LOG")
+
+	compareOptions := &cli.CompareOptions{
+		GlobalOptions: &cli.GlobalOptions{
+			OcBinary:         fakeOcBinary,
+			Namespace:        "foo-dev",
+			NamespaceMissing: true,
+			NonInteractive:   true,
+			TemplateDirs:     []string{templateDir},
+			ParamDirs:        []string{"."},
+		},
+		CreateNamespace:      true,
+		NamespaceDisplayName: "Foo Dev",
+		NamespaceLabels:      []string{"team=foo"},
+	}
+	err = compareOptions.GlobalOptions.Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changeset, applied, err := update(compareOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changeset.Blank() {
+		t.Errorf("Expected no resource changes")
+	}
+	if !applied {
+		t.Errorf("Missing namespace should be created even without resources")
+	}
+	log, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"new-project foo-dev --skip-config-write --display-name=Foo Dev",
+		"label namespace foo-dev --overwrite team=foo",
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(log), e) {
+			t.Errorf("Expected oc call %q, got:\n%s", e, log)
+		}
+	}
+}
+
+func TestUpdateRequiresForceForClusterScopedChangesInNonInteractiveMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailor-update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	templateDir := filepath.Join(dir, "templates")
+	err = os.Mkdir(templateDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(templateDir, "role.yml"), []byte("apiVersion: v1\nkind: Template\nobjects: []\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The templates are processed into a single cluster role, which does
+	// not exist yet and may be created by the current user.
+	logFile := filepath.Join(dir, "oc.log")
+	os.Setenv("TAILOR_TEST_OC_LOG", logFile)
+	defer os.Unsetenv("TAILOR_TEST_OC_LOG")
+	os.Setenv("TAILOR_TEST_PROCESS_OUTPUT", "../testdata/fake-oc/cluster-role.yml")
+	defer os.Unsetenv("TAILOR_TEST_PROCESS_OUTPUT")
+
+	compareOptions := &cli.CompareOptions{
+		GlobalOptions: &cli.GlobalOptions{
+			OcBinary:         fakeOcBinary,
+			Namespace:        "foo-dev",
+			NamespaceMissing: true,
+			NonInteractive:   true,
+			TemplateDirs:     []string{templateDir},
+			ParamDirs:        []string{"."},
+		},
+		ClusterScoped: true,
+	}
+	err = compareOptions.GlobalOptions.Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, applied, err := update(compareOptions)
+	if err == nil || !strings.Contains(err.Error(), "use --force") {
+		t.Fatalf("Expected update to require --force, got: %v", err)
+	}
+	if applied {
+		t.Errorf("Cluster-scoped changes should not be applied without --force")
+	}
+
+	compareOptions.Force = true
+	_, applied, err = update(compareOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !applied {
+		t.Errorf("Cluster-scoped changes should be applied with --force")
+	}
+	log, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(log), "create -f -") != 1 {
+		t.Errorf("Expected cluster role to be created once, got:\n%s", log)
+	}
+}
@@ -47,7 +47,7 @@ var (
 	).Short('l').String()
 	excludeFlag = app.Flag(
 		"exclude",
-		"Exclude kinds, names and labels (comma separated)",
+		"Exclude kinds, names, labels and annotations (comma separated)",
 	).Short('e').String()
 	templateDirFlag = app.Flag(
 		"template-dir",
@@ -73,6 +73,10 @@ var (
 		"force",
 		"Force to continue despite warning (e.g. deleting all resources).",
 	).Bool()
+	rulesFileFlag = app.Flag(
+		"rules-file",
+		"File with additional rules for platform-managed, immutable and platform-modified fields.",
+	).String()
 
 	versionCommand = app.Command(
 		"version",
@@ -95,9 +99,13 @@ var (
 		"param-file",
 		"File(s) containing template parameter values to set/override in the template.",
 	).Strings()
+	statusExplainParamFlag = statusCommand.Flag(
+		"explain-param",
+		"Show which param layer provides the value of given parameter (can be repeated).",
+	).PlaceHolder("NAME").Strings()
 	statusDiffFlag = statusCommand.Flag(
 		"diff",
-		"Type of diff (text or json)",
+		"Type of diff (text, json, structural, word or side-by-side)",
 	).Default("text").String()
 	statusIgnorePathFlag = statusCommand.Flag(
 		"ignore-path",
@@ -111,6 +119,22 @@ var (
 		"upsert-only",
 		"Don't delete resource, only create / update.",
 	).Short('u').Bool()
+	statusIgnoreDefaultsFlag = statusCommand.Flag(
+		"ignore-defaults",
+		"Ignore fields set to their server default when comparing.",
+	).Bool()
+	statusRevealSecretsFlag = statusCommand.Flag(
+		"reveal-secrets",
+		"Show decoded secret values in the diff instead of masking them.",
+	).Bool()
+	statusClusterScopedFlag = statusCommand.Flag(
+		"cluster-scoped",
+		"Manage cluster-scoped resources (e.g. ClusterRole) defined in the templates.",
+	).Bool()
+	statusCreateNamespaceFlag = statusCommand.Flag(
+		"create-namespace",
+		"Create the namespace if it does not exist yet.",
+	).Bool()
 	statusResourceArg = statusCommand.Arg(
 		"resource", "Remote resource (defaults to all)",
 	).String()
@@ -131,9 +155,13 @@ var (
 		"param-file",
 		"File(s) containing template parameter values to set/override in the template.",
 	).Strings()
+	updateExplainParamFlag = updateCommand.Flag(
+		"explain-param",
+		"Show which param layer provides the value of given parameter (can be repeated).",
+	).PlaceHolder("NAME").Strings()
 	updateDiffFlag = updateCommand.Flag(
 		"diff",
-		"Type of diff (text or json)",
+		"Type of diff (text, json, structural, word or side-by-side)",
 	).Default("text").String()
 	updateIgnorePathFlag = updateCommand.Flag(
 		"ignore-path",
@@ -147,18 +175,176 @@ var (
 		"upsert-only",
 		"Don't delete resource, only create / update.",
 	).Short('u').Bool()
+	updateIgnoreDefaultsFlag = updateCommand.Flag(
+		"ignore-defaults",
+		"Ignore fields set to their server default when comparing.",
+	).Bool()
+	updateRevealSecretsFlag = updateCommand.Flag(
+		"reveal-secrets",
+		"Show decoded secret values in the diff instead of masking them.",
+	).Bool()
+	updateClusterScopedFlag = updateCommand.Flag(
+		"cluster-scoped",
+		"Manage cluster-scoped resources (e.g. ClusterRole) defined in the templates.",
+	).Bool()
+	updateCreateNamespaceFlag = updateCommand.Flag(
+		"create-namespace",
+		"Create the namespace if it does not exist yet.",
+	).Bool()
 	updateResourceArg = updateCommand.Arg(
 		"resource", "Remote resource (defaults to all)",
 	).String()
 
+	adoptCommand = app.Command(
+		"adopt",
+		"Write template for remote resources and mark them as managed",
+	)
+	adoptTemplateFileFlag = adoptCommand.Flag(
+		"template-file",
+		"Name of the template file to write into the (first) template directory.",
+	).Default("adopted.yml").String()
+	adoptParamFlag = adoptCommand.Flag(
+		"param",
+		"Specify a key-value pair (eg. -p FOO=BAR) to set/override a parameter value in the template.",
+	).Strings()
+	adoptParamFileFlag = adoptCommand.Flag(
+		"param-file",
+		"File(s) containing template parameter values to set/override in the template.",
+	).Strings()
+	adoptDiffFlag = adoptCommand.Flag(
+		"diff",
+		"Type of diff (text, json, structural, word or side-by-side)",
+	).Default("text").String()
+	adoptIgnorePathFlag = adoptCommand.Flag(
+		"ignore-path",
+		"Path(s) per kind/name to ignore (e.g. because they are externally modified) in RFC 6901 format.",
+	).PlaceHolder("bc:foobar:/spec/output/to/name").Strings()
+	adoptIgnoreUnknownParametersFlag = adoptCommand.Flag(
+		"ignore-unknown-parameters",
+		"If true, will not stop processing if a provided parameter does not exist in the template.",
+	).Bool()
+	adoptIgnoreDefaultsFlag = adoptCommand.Flag(
+		"ignore-defaults",
+		"Ignore fields set to their server default when comparing.",
+	).Bool()
+	adoptRevealSecretsFlag = adoptCommand.Flag(
+		"reveal-secrets",
+		"Show decoded secret values in the diff instead of masking them.",
+	).Bool()
+	adoptResourceArg = adoptCommand.Arg(
+		"resource", "Remote resource (defaults to all)",
+	).String()
+
 	exportCommand = app.Command(
 		"export",
 		"Export remote state as template",
 	)
+	exportOutputDirFlag = exportCommand.Flag(
+		"output-dir",
+		"Write one template per kind or label value into this directory instead of STDOUT.",
+	).String()
+	exportSplitByFlag = exportCommand.Flag(
+		"split-by",
+		"How to split templates in --output-dir (kind or label, defaults to kind)",
+	).String()
+	exportSplitLabelFlag = exportCommand.Flag(
+		"split-label",
+		"Label to split templates by when using --split-by=label (defaults to app)",
+	).String()
+	exportParameterizeFlag = exportCommand.Flag(
+		"parameterize",
+		"Replace namespace-specific values with parameters (requires --output-dir).",
+	).Bool()
+	exportParamRuleFlag = exportCommand.Flag(
+		"param-rule",
+		"String value, or JSON path starting with /, to replace with a parameter when using --parameterize.",
+	).PlaceHolder("foo.example.com=ROUTE_HOST").Strings()
+	exportEncryptSecretsFlag = exportCommand.Flag(
+		"encrypt-secrets",
+		"Replace secret data with parameters stored in encrypted param files (requires --output-dir).",
+	).Bool()
+	exportStripDefaultsFlag = exportCommand.Flag(
+		"strip-defaults",
+		"Remove fields which are set to their server default.",
+	).Bool()
 	exportResourceArg = exportCommand.Arg(
 		"resource", "Remote resource (defaults to all)",
 	).String()
 
+	cloneCommand = app.Command(
+		"clone",
+		"Copy remote resources of one namespace into another",
+	)
+	cloneFromFlag = cloneCommand.Flag(
+		"from",
+		"Namespace to copy resources from.",
+	).Required().String()
+	cloneToFlag = cloneCommand.Flag(
+		"to",
+		"Namespace to copy resources into.",
+	).Required().String()
+	cloneSkipSecretsFlag = cloneCommand.Flag(
+		"skip-secrets",
+		"Do not copy secrets.",
+	).Bool()
+	cloneSkipPVCsFlag = cloneCommand.Flag(
+		"skip-pvcs",
+		"Do not copy persistent volume claims.",
+	).Bool()
+	cloneDryRunFlag = cloneCommand.Flag(
+		"dry-run",
+		"Show what would be copied without applying it.",
+	).Bool()
+	cloneResourceArg = cloneCommand.Arg(
+		"resource", "Remote resource (defaults to all)",
+	).String()
+
+	promoteCommand = app.Command(
+		"promote",
+		"Compare the desired state of two namespaces and promote parameters",
+	)
+	promoteFromFlag = promoteCommand.Flag(
+		"from",
+		"Namespace to promote from.",
+	).Required().String()
+	promoteToFlag = promoteCommand.Flag(
+		"to",
+		"Namespace to promote to.",
+	).Required().String()
+	promoteCopyParamFlag = promoteCommand.Flag(
+		"copy-param",
+		"Parameter whose value to copy from the source to the target param files.",
+	).Strings()
+	promoteDiffFlag = promoteCommand.Flag(
+		"diff",
+		"Type of diff (text, json, structural, word or side-by-side)",
+	).Default("text").String()
+	promoteResourceArg = promoteCommand.Arg(
+		"resource", "Remote resource (defaults to all)",
+	).String()
+
+	paramsCommand = app.Command(
+		"params",
+		"List template parameters and check param files",
+	)
+	paramsParamFlag = paramsCommand.Flag(
+		"param",
+		"Specify a key-value pair (eg. -p FOO=BAR) to set/override a parameter value in the template.",
+	).Strings()
+	paramsParamFileFlag = paramsCommand.Flag(
+		"param-file",
+		"File(s) containing template parameter values to set/override in the template.",
+	).Strings()
+	paramsExplainParamFlag = paramsCommand.Flag(
+		"explain-param",
+		"Show which param layer provides the value of given parameter (can be repeated).",
+	).PlaceHolder("NAME").Strings()
+
+	rulesCommand = app.Command(
+		"rules",
+		"Show rules for platform-managed, immutable and platform-modified fields",
+	)
+
 	secretsCommand = app.Command(
 		"secrets",
 		"Work with secrets",
@@ -219,26 +405,14 @@ func main() {
 	if err != nil {
 		log.Fatalln("Could not read Tailorfile:", err)
 	}
-	globalOptions := &cli.GlobalOptions{}
-	globalOptions.UpdateWithFile(fileFlags)
-	globalOptions.UpdateWithFlags(
-		*verboseFlag,
-		*debugFlag,
-		*nonInteractiveFlag,
-		*ocBinaryFlag,
-		*namespaceFlag,
-		*selectorFlag,
-		*excludeFlag,
-		*templateDirFlag,
-		*paramDirFlag,
-		*publicKeyDirFlag,
-		*privateKeyFlag,
-		*passphraseFlag,
-		*forceFlag,
-	)
-	err = globalOptions.Process()
+	fileSections, err := cli.GetFileSections(*fileFlag, false)
 	if err != nil {
-		log.Fatalln("Options could not be processed:", err)
+		log.Fatalln("Could not read Tailorfile:", err)
+	}
+	globalOptions := newGlobalOptions(fileFlags)
+	err = commands.LoadRules(globalOptions)
+	if err != nil {
+		log.Fatalln("Rules could not be loaded:", err)
 	}
 
 	switch command {
@@ -266,27 +440,44 @@ func main() {
 			log.Fatalf("Failed to generate keypair: %s.", err)
 		}
 
+	case rulesCommand.FullCommand():
+		commands.Rules()
+
 	case statusCommand.FullCommand():
-		compareOptions := &cli.CompareOptions{
-			GlobalOptions: globalOptions,
-		}
-		compareOptions.UpdateWithFile(fileFlags)
-		compareOptions.UpdateWithFlags(
-			*statusLabelsFlag,
-			*statusParamFlag,
-			*statusParamFileFlag,
-			*statusDiffFlag,
-			*statusIgnorePathFlag,
-			*statusIgnoreUnknownParametersFlag,
-			*statusUpsertOnlyFlag,
-			*statusResourceArg,
-		)
-		err := compareOptions.Process()
-		if err != nil {
-			log.Fatalln("Options could not be processed:", err)
+		optionsList := []*cli.CompareOptions{}
+		for _, runFlags := range namespaceRuns(fileFlags, fileSections) {
+			compareOptions := &cli.CompareOptions{
+				GlobalOptions: newGlobalOptions(runFlags),
+			}
+			compareOptions.UpdateWithFile(runFlags)
+			compareOptions.UpdateWithFlags(
+				*statusLabelsFlag,
+				*statusParamFlag,
+				*statusParamFileFlag,
+				*statusExplainParamFlag,
+				*statusDiffFlag,
+				*statusIgnorePathFlag,
+				*statusIgnoreUnknownParametersFlag,
+				*statusUpsertOnlyFlag,
+				*statusIgnoreDefaultsFlag,
+				*statusRevealSecretsFlag,
+				*statusClusterScopedFlag,
+				*statusCreateNamespaceFlag,
+				*statusResourceArg,
+			)
+			err := compareOptions.Process()
+			if err != nil {
+				log.Fatalln("Options could not be processed:", err)
+			}
+			optionsList = append(optionsList, compareOptions)
 		}
 
-		updateRequired, _, err := commands.Status(compareOptions)
+		var updateRequired bool
+		if len(optionsList) == 1 {
+			updateRequired, _, err = commands.Status(optionsList[0])
+		} else {
+			updateRequired, err = commands.StatusNamespaces(optionsList)
+		}
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -295,29 +486,78 @@ func main() {
 		}
 
 	case updateCommand.FullCommand():
-		compareOptions := &cli.CompareOptions{
-			GlobalOptions: globalOptions,
+		optionsList := []*cli.CompareOptions{}
+		for _, runFlags := range namespaceRuns(fileFlags, fileSections) {
+			compareOptions := &cli.CompareOptions{
+				GlobalOptions: newGlobalOptions(runFlags),
+			}
+			compareOptions.UpdateWithFile(runFlags)
+			compareOptions.UpdateWithFlags(
+				*updateLabelsFlag,
+				*updateParamFlag,
+				*updateParamFileFlag,
+				*updateExplainParamFlag,
+				*updateDiffFlag,
+				*updateIgnorePathFlag,
+				*updateIgnoreUnknownParametersFlag,
+				*updateUpsertOnlyFlag,
+				*updateIgnoreDefaultsFlag,
+				*updateRevealSecretsFlag,
+				*updateClusterScopedFlag,
+				*updateCreateNamespaceFlag,
+				*updateResourceArg,
+			)
+			err := compareOptions.Process()
+			if err != nil {
+				log.Fatalln("Options could not be processed:", err)
+			}
+			optionsList = append(optionsList, compareOptions)
 		}
-		compareOptions.UpdateWithFile(fileFlags)
-		compareOptions.UpdateWithFlags(
-			*updateLabelsFlag,
-			*updateParamFlag,
-			*updateParamFileFlag,
-			*updateDiffFlag,
-			*updateIgnorePathFlag,
-			*updateIgnoreUnknownParametersFlag,
-			*updateUpsertOnlyFlag,
-			*updateResourceArg,
+
+		if len(optionsList) == 1 {
+			err = commands.Update(optionsList[0])
+		} else {
+			err = commands.UpdateNamespaces(optionsList)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+	case adoptCommand.FullCommand():
+		adoptOptions := &cli.AdoptOptions{
+			CompareOptions: &cli.CompareOptions{
+				GlobalOptions: globalOptions,
+			},
+		}
+		adoptOptions.UpdateWithFile(fileFlags)
+		adoptOptions.CompareOptions.UpdateWithFlags(
+			"",
+			*adoptParamFlag,
+			*adoptParamFileFlag,
+			[]string{},
+			*adoptDiffFlag,
+			*adoptIgnorePathFlag,
+			*adoptIgnoreUnknownParametersFlag,
+			false,
+			*adoptIgnoreDefaultsFlag,
+			*adoptRevealSecretsFlag,
+			false,
+			false,
+			*adoptResourceArg,
 		)
-		err := compareOptions.Process()
+		adoptOptions.UpdateWithFlags(*adoptTemplateFileFlag)
+		err := adoptOptions.Process()
 		if err != nil {
 			log.Fatalln("Options could not be processed:", err)
 		}
 
-		err = commands.Update(compareOptions)
+		updateRequired, err := commands.Adopt(adoptOptions)
 		if err != nil {
 			log.Fatalln(err)
 		}
+		if updateRequired {
+			os.Exit(3)
+		}
 
 	case exportCommand.FullCommand():
 		exportOptions := &cli.ExportOptions{
@@ -325,6 +565,13 @@ func main() {
 		}
 		exportOptions.UpdateWithFile(fileFlags)
 		exportOptions.UpdateWithFlags(
+			*exportOutputDirFlag,
+			*exportSplitByFlag,
+			*exportSplitLabelFlag,
+			*exportParameterizeFlag,
+			*exportParamRuleFlag,
+			*exportEncryptSecretsFlag,
+			*exportStripDefaultsFlag,
 			*exportResourceArg,
 		)
 		err := exportOptions.Process()
@@ -335,5 +582,174 @@ func main() {
 		if err != nil {
 			log.Fatalln(err)
 		}
+
+	case promoteCommand.FullCommand():
+		promoteOptions := &cli.PromoteOptions{
+			From: newPromoteCompareOptions(*promoteFromFlag, fileFlags, fileSections),
+			To:   newPromoteCompareOptions(*promoteToFlag, fileFlags, fileSections),
+		}
+		promoteOptions.UpdateWithFlags(*promoteCopyParamFlag)
+		err := promoteOptions.Process()
+		if err != nil {
+			log.Fatalln("Options could not be processed:", err)
+		}
+		updateRequired, err := commands.Promote(promoteOptions)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if updateRequired {
+			os.Exit(3)
+		}
+
+	case paramsCommand.FullCommand():
+		optionsList := []*cli.CompareOptions{}
+		for _, runFlags := range namespaceRuns(fileFlags, fileSections) {
+			compareOptions := &cli.CompareOptions{
+				GlobalOptions: newGlobalOptions(runFlags),
+			}
+			compareOptions.UpdateWithFile(runFlags)
+			compareOptions.UpdateWithFlags(
+				"",
+				*paramsParamFlag,
+				*paramsParamFileFlag,
+				*paramsExplainParamFlag,
+				"text",
+				[]string{},
+				false,
+				false,
+				false,
+				false,
+				false,
+				false,
+				"",
+			)
+			err := compareOptions.ProcessWithoutCluster()
+			if err != nil {
+				log.Fatalln("Options could not be processed:", err)
+			}
+			optionsList = append(optionsList, compareOptions)
+		}
+
+		var problemsFound bool
+		if len(optionsList) == 1 {
+			problemsFound, err = commands.Params(optionsList[0])
+		} else {
+			problemsFound, err = commands.ParamsNamespaces(optionsList)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if problemsFound {
+			os.Exit(3)
+		}
+
+	case cloneCommand.FullCommand():
+		cloneOptions := &cli.CloneOptions{
+			GlobalOptions: globalOptions,
+		}
+		cloneOptions.UpdateWithFile(fileFlags)
+		cloneOptions.UpdateWithFlags(
+			*cloneFromFlag,
+			*cloneToFlag,
+			*cloneSkipSecretsFlag,
+			*cloneSkipPVCsFlag,
+			*cloneDryRunFlag,
+			*cloneResourceArg,
+		)
+		err := cloneOptions.Process()
+		if err != nil {
+			log.Fatalln("Options could not be processed:", err)
+		}
+		err = commands.Clone(cloneOptions)
+		if err != nil {
+			log.Fatalln(err)
+		}
 	}
 }
+
+// newGlobalOptions returns the global options based on given file flags and
+// the command line flags.
+func newGlobalOptions(fileFlags map[string]string) *cli.GlobalOptions {
+	globalOptions := &cli.GlobalOptions{}
+	globalOptions.UpdateWithFile(fileFlags)
+	globalOptions.UpdateWithFlags(
+		*verboseFlag,
+		*debugFlag,
+		*nonInteractiveFlag,
+		*ocBinaryFlag,
+		*namespaceFlag,
+		*selectorFlag,
+		*excludeFlag,
+		*templateDirFlag,
+		*paramDirFlag,
+		*publicKeyDirFlag,
+		*privateKeyFlag,
+		*passphraseFlag,
+		*forceFlag,
+		*rulesFileFlag,
+	)
+	err := globalOptions.Process()
+	if err != nil {
+		log.Fatalln("Options could not be processed:", err)
+	}
+	return globalOptions
+}
+
+// namespaceRuns returns the file flags to use for each namespace targeted by
+// status and update. If the Tailorfile declares namespace sections, there is
+// one run per section, or only the one matching --namespace. Otherwise,
+// there is one run based on the flags outside of any section.
+func namespaceRuns(fileFlags map[string]string, fileSections []*cli.FileSection) []map[string]string {
+	if len(*namespaceFlag) > 0 {
+		for _, section := range fileSections {
+			if section.Namespace == *namespaceFlag {
+				return []map[string]string{section.Flags}
+			}
+		}
+		return []map[string]string{fileFlags}
+	}
+	if len(fileSections) == 0 {
+		return []map[string]string{fileFlags}
+	}
+	runs := []map[string]string{}
+	for _, section := range fileSections {
+		runs = append(runs, section.Flags)
+	}
+	return runs
+}
+
+// newPromoteCompareOptions returns the compare options for given namespace,
+// based on its section in the Tailorfile if there is one, and on the flags
+// outside of any section otherwise.
+func newPromoteCompareOptions(namespace string, fileFlags map[string]string, fileSections []*cli.FileSection) *cli.CompareOptions {
+	namespaceFlags := map[string]string{}
+	for k, v := range fileFlags {
+		namespaceFlags[k] = v
+	}
+	for _, section := range fileSections {
+		if section.Namespace == namespace {
+			namespaceFlags = section.Flags
+		}
+	}
+	compareOptions := &cli.CompareOptions{
+		GlobalOptions: newGlobalOptions(namespaceFlags),
+	}
+	compareOptions.UpdateWithFile(namespaceFlags)
+	compareOptions.UpdateWithFlags(
+		"",
+		[]string{},
+		[]string{},
+		[]string{},
+		*promoteDiffFlag,
+		[]string{},
+		false,
+		false,
+		false,
+		false,
+		false,
+		false,
+		*promoteResourceArg,
+	)
+	compareOptions.Namespace = namespace
+	return compareOptions
+}
@@ -0,0 +1,61 @@
+package openshift
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/opendevstack/tailor/cli"
+)
+
+var (
+	tailorOwnerAnnotation = "owner.tailor.opendevstack.org"
+)
+
+// AdoptionAnnotations returns the annotations which need to be set on the
+// platform item so that Tailor treats it as managed by given template.
+// The ownership marker is not tracked in templates, and is therefore
+// removed before comparison like any other unmanaged annotation.
+func (i *ResourceItem) AdoptionAnnotations(templateFile string) map[string]string {
+	annotations := map[string]string{
+		tailorOwnerAnnotation: templateFile,
+	}
+	managed := []string{}
+	for _, a := range i.TailorManagedAnnotations {
+		if _, ok := i.Annotations[a]; ok {
+			managed = append(managed, a)
+		}
+	}
+	if len(managed) > 0 {
+		sort.Strings(managed)
+		annotations[tailorManagedAnnotation] = strings.Join(managed, ",")
+	}
+	return annotations
+}
+
+// MarkAsManaged stamps the adoption annotations on the live resource.
+func MarkAsManaged(item *ResourceItem, namespace string, templateFile string) error {
+	annotations := item.AdoptionAnnotations(templateFile)
+	keys := []string{}
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := []string{"annotate", item.FullName(), "--overwrite"}
+	for _, k := range keys {
+		args = append(args, k+"="+annotations[k])
+	}
+	cmd := cli.ExecOcCmd(
+		args,
+		namespace,
+		"", // empty as name and selector is not allowed
+	)
+	_, errBytes, err := cli.RunCmd(cmd)
+	if err != nil {
+		return errors.New(string(errBytes))
+	}
+	cli.DebugMsg(fmt.Sprintf("Annotated %s with %v", item.FullName(), annotations))
+	return nil
+}
@@ -0,0 +1,45 @@
+package openshift
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAdoptionAnnotations(t *testing.T) {
+	unmanagedItem := getItem(t, getConfigMap([]byte("{foo: bar}")), "platform")
+	unmanagedItem.RemoveUnmanagedAnnotations()
+	actual := unmanagedItem.AdoptionAnnotations("foo.yml")
+	expected := map[string]string{
+		"owner.tailor.opendevstack.org": "foo.yml",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %v instead of %v", actual, expected)
+	}
+
+	managedItem := getItem(t, getConfigMap([]byte(
+		"{foo: bar, baz: qux, managed-annotations.tailor.opendevstack.org: foo}",
+	)), "platform")
+	managedItem.RemoveUnmanagedAnnotations()
+	actual = managedItem.AdoptionAnnotations("foo.yml")
+	expected = map[string]string{
+		"owner.tailor.opendevstack.org":               "foo.yml",
+		"managed-annotations.tailor.opendevstack.org": "foo",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %v instead of %v", actual, expected)
+	}
+}
+
+func TestAdoptedItemHasNoDrift(t *testing.T) {
+	platformItem := getItem(t, getConfigMap([]byte(
+		"{owner.tailor.opendevstack.org: foo.yml}",
+	)), "platform")
+	templateItem := getItem(t, getConfigMap([]byte("{}")), "template")
+	changes, err := templateItem.ChangesFrom(platformItem, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Action != "Noop" {
+		t.Errorf("Ownership marker should not cause drift, got %s", changes[0].JsonPatches(true))
+	}
+}
@@ -20,6 +20,10 @@ var (
 		"Secret":                "secret",
 		"RoleBinding":           "rolebinding",
 		"ServiceAccount":        "serviceaccount",
+		"Project":               "project",
+		"ClusterResourceQuota":  "clusterresourcequota",
+		"ClusterRole":           "clusterrole",
+		"ClusterRoleBinding":    "clusterrolebinding",
 	}
 )
 
@@ -30,6 +34,9 @@ type Change struct {
 	Patches      []*jsonPatch
 	CurrentState string
 	DesiredState string
+	// Origin describes where the desired state is defined, see
+	// ResourceItem.Origin.
+	Origin string
 }
 
 type jsonPatch struct {
@@ -42,6 +49,15 @@ func (c *Change) ItemName() string {
 	return kindToShortMapping[c.Kind] + "/" + c.Name
 }
 
+// ItemNameWithOrigin returns the item name, followed by the origin of the
+// desired state if known.
+func (c *Change) ItemNameWithOrigin() string {
+	if len(c.Origin) == 0 {
+		return c.ItemName()
+	}
+	return c.ItemName() + " (" + c.Origin + ")"
+}
+
 func (c *Change) JsonPatches(pretty bool) string {
 	var b []byte
 	if pretty {
@@ -52,6 +68,29 @@ func (c *Change) JsonPatches(pretty bool) string {
 	return string(b)
 }
 
+// JsonReport returns the JSON patches of the change together with the origin
+// of the desired state. Values of secret data are masked unless reveal is
+// true.
+func (c *Change) JsonReport(pretty bool, reveal bool) string {
+	report := struct {
+		Origin  string       `json:"origin,omitempty"`
+		Patches []*jsonPatch `json:"patches"`
+	}{
+		Origin:  c.Origin,
+		Patches: c.Patches,
+	}
+	if c.Kind == "Secret" && !reveal {
+		report.Patches = c.maskedPatches()
+	}
+	var b []byte
+	if pretty {
+		b, _ = json.MarshalIndent(report, "", "  ")
+	} else {
+		b, _ = json.Marshal(report)
+	}
+	return string(b)
+}
+
 func (c *Change) Diff() string {
 	diff := difflib.UnifiedDiff{
 		A:        difflib.SplitLines(c.CurrentState),
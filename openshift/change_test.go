@@ -281,3 +281,104 @@ data: DATA`)
 	config = bytes.Replace(config, []byte("ANNOTATIONS"), annotations, -1)
 	return bytes.Replace(config, []byte("DATA"), data, -1)
 }
+
+func TestStructuralDiff(t *testing.T) {
+	current := `apiVersion: v1
+kind: DeploymentConfig
+metadata:
+  name: foo
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - env:
+        - name: FOO
+          value: bar
+        - name: JAVA_OPTS
+          value: -Xmx256m
+        name: app
+      volumes:
+      - emptyDir: {}
+`
+	desired := `apiVersion: v1
+kind: DeploymentConfig
+metadata:
+  labels:
+    app: foo
+  name: foo
+spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - name: FOO
+          value: bar
+        - name: JAVA_OPTS
+          value: -Xmx512m
+        name: app
+      volumes:
+      - emptyDir: {medium: Memory}
+`
+	c := &Change{
+		Action: "Update",
+		Kind:   "DeploymentConfig",
+		Name:   "foo",
+		Patches: []*jsonPatch{
+			&jsonPatch{Op: "add", Path: "/metadata/labels", Value: map[string]interface{}{"app": "foo"}},
+			&jsonPatch{Op: "remove", Path: "/spec/replicas"},
+			&jsonPatch{Op: "replace", Path: "/spec/template/spec/containers/0/env/1/value", Value: "-Xmx512m"},
+			&jsonPatch{Op: "add", Path: "/spec/template/spec/volumes/0/emptyDir/medium", Value: "Memory"},
+			&jsonPatch{Op: "noop", Path: "/spec/template/spec/containers/0/name"},
+		},
+		CurrentState: current,
+		DesiredState: desired,
+	}
+	expected := `  /metadata/labels: (none) → {"app":"foo"}
+  /spec/replicas: 1 → (none)
+  /spec/template/spec/containers[name=app]/env[name=JAVA_OPTS]/value: "-Xmx256m" → "-Xmx512m"
+  /spec/template/spec/volumes/0/emptyDir/medium: (none) → "Memory"
+`
+	actual := c.StructuralDiff()
+	if actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+}
+
+func TestStructuralValueCollapsesLargeValues(t *testing.T) {
+	m := map[string]interface{}{}
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		m[k] = "some longer value"
+	}
+	if actual := structuralValue(m); actual != "{...} (10 keys)" {
+		t.Errorf("Got %s", actual)
+	}
+}
+
+func TestJsonReport(t *testing.T) {
+	c := &Change{
+		Action: "Update",
+		Kind:   "ConfigMap",
+		Name:   "foo",
+		Origin: "templates/foo.yml, object #1",
+		Patches: []*jsonPatch{
+			&jsonPatch{Op: "replace", Path: "/data/foo", Value: "baz"},
+		},
+	}
+	expected := `{"origin":"templates/foo.yml, object #1","patches":[{"op":"replace","path":"/data/foo","value":"baz"}]}`
+	if actual := c.JsonReport(false, false); actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+
+	// "baz" => YmF6
+	c.Kind = "Secret"
+	c.Patches[0].Value = "YmF6"
+	expected = `{"origin":"templates/foo.yml, object #1","patches":[{"op":"replace","path":"/data/foo","value":"(3 bytes, sha256:baa5a096)"}]}`
+	if actual := c.JsonReport(false, false); actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+	expected = `{"origin":"templates/foo.yml, object #1","patches":[{"op":"replace","path":"/data/foo","value":"YmF6"}]}`
+	if actual := c.JsonReport(false, true); actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+}
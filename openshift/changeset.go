@@ -3,12 +3,16 @@ package openshift
 import (
 	"fmt"
 	"sort"
-	"strings"
 )
 
 var (
-	// Resources with no dependencies go first
+	// Resources with no dependencies go first, cluster-scoped resources go
+	// before namespaced ones
 	kindOrder = map[string]string{
+		"Project":               "0a",
+		"ClusterResourceQuota":  "0b",
+		"ClusterRole":           "0c",
+		"ClusterRoleBinding":    "0d",
 		"Template":              "a",
 		"ConfigMap":             "b",
 		"Secret":                "c",
@@ -28,6 +32,7 @@ type Changeset struct {
 	Update []*Change
 	Delete []*Change
 	Noop   []*Change
+	Rename []*Rename
 }
 
 func NewChangeset(platformBasedList, templateBasedList *ResourceList, upsertOnly bool, ignoredPaths []string) (*Changeset, error) {
@@ -36,6 +41,24 @@ func NewChangeset(platformBasedList, templateBasedList *ResourceList, upsertOnly
 		Delete: []*Change{},
 		Update: []*Change{},
 		Noop:   []*Change{},
+		Rename: []*Rename{},
+	}
+
+	// ignored paths can be either:
+	// - globally (e.g. /spec/name)
+	// - per-kind (e.g. bc:/spec/name)
+	// - per-resource (e.g. bc:foo:/spec/name)
+	// - per name pattern or label (e.g. dc:~^foo-:/spec/replicas or
+	//   dc:autoscaled=true:/spec/replicas)
+	// The path itself may contain wildcards (e.g. /spec/containers/*/image)
+	// and array selectors (e.g. /spec/containers[name=foo]/image).
+	parsedIgnoredPaths := []*IgnorePath{}
+	for _, path := range ignoredPaths {
+		ip, err := ParseIgnorePath(path)
+		if err != nil {
+			return changeset, err
+		}
+		parsedIgnoredPaths = append(parsedIgnoredPaths, ip)
 	}
 
 	// items to delete
@@ -63,11 +86,14 @@ func NewChangeset(platformBasedList, templateBasedList *ResourceList, upsertOnly
 				Name:         item.Name,
 				CurrentState: "",
 				DesiredState: item.YamlConfig(),
+				Origin:       item.Origin(),
 			}
 			changeset.Add(change)
 		}
 	}
 
+	changeset.detectRenames()
+
 	// items to update
 	for _, templateItem := range templateBasedList.Items {
 		platformItem, err := platformBasedList.getItem(
@@ -76,33 +102,37 @@ func NewChangeset(platformBasedList, templateBasedList *ResourceList, upsertOnly
 		)
 		if err == nil {
 			externallyModifiedPaths := []string{}
-			for _, path := range ignoredPaths {
-				pathParts := strings.Split(path, ":")
-				if len(pathParts) > 3 {
+			// Paths declared in the template via annotation apply to the
+			// item itself only.
+			for _, path := range templateItem.IgnorePaths {
+				if _, err := parsePathSegments(path); err != nil {
 					return changeset, fmt.Errorf(
-						"%s is not a valid ignore-path argument",
-						path,
+						"%s has an invalid %s annotation: %s",
+						templateItemDescription(templateItem),
+						tailorIgnorePathsAnnotation,
+						err,
 					)
 				}
-				// ignored paths can be either:
-				// - globally (e.g. /spec/name)
-				// - per-kind (e.g. bc:/spec/name)
-				// - per-resource (e.g. bc:foo:/spec/name)
-				if len(pathParts) == 1 ||
-					(len(pathParts) == 2 &&
-						templateItem.Kind == KindMapping[strings.ToLower(pathParts[0])]) ||
-					(len(pathParts) == 3 &&
-						templateItem.Kind == KindMapping[strings.ToLower(pathParts[0])] &&
-						templateItem.Name == strings.ToLower(pathParts[1])) {
-					// We only care about the last part (the JSON path) as we
-					// are already "inside" the item
-					externallyModifiedPaths = append(externallyModifiedPaths, pathParts[len(pathParts)-1])
+				externallyModifiedPaths = append(externallyModifiedPaths, path)
+			}
+			for _, ip := range parsedIgnoredPaths {
+				if ip.AppliesTo(templateItem) {
+					// We only care about the path as we are already
+					// "inside" the item
+					externallyModifiedPaths = append(externallyModifiedPaths, ip.Path)
 				}
 			}
 
 			changes, err := templateItem.ChangesFrom(platformItem, externallyModifiedPaths)
 			if err != nil {
-				return changeset, err
+				return changeset, fmt.Errorf(
+					"Could not compare %s: %s",
+					templateItemDescription(templateItem),
+					err,
+				)
+			}
+			for _, change := range changes {
+				change.Origin = templateItem.Origin()
 			}
 			changeset.Add(changes...)
 		}
@@ -111,6 +141,15 @@ func NewChangeset(platformBasedList, templateBasedList *ResourceList, upsertOnly
 	return changeset, nil
 }
 
+// templateItemDescription returns the full name of the item, followed by
+// its origin if known.
+func templateItemDescription(item *ResourceItem) string {
+	if origin := item.Origin(); len(origin) > 0 {
+		return item.FullName() + " (" + origin + ")"
+	}
+	return item.FullName()
+}
+
 func (c *Changeset) Blank() bool {
 	return len(c.Create) == 0 && len(c.Update) == 0 && len(c.Delete) == 0
 }
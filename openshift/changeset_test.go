@@ -1,6 +1,7 @@
 package openshift
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -237,6 +238,56 @@ objects:
 	}
 }
 
+func TestConfigIgnoredPathsViaAnnotation(t *testing.T) {
+	templateInput := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    annotations:
+      tailor.opendevstack.org/ignore-paths: /spec/replicas, /spec/triggers
+    name: foo
+  spec:
+    replicas: 1
+    triggers:
+    - type: ConfigChange`)
+
+	platformInput := []byte(
+		`kind: Template
+apiVersion: v1
+objects:
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    annotations:
+      managed-annotations.tailor.opendevstack.org: tailor.opendevstack.org/ignore-paths
+      tailor.opendevstack.org/ignore-paths: /spec/replicas, /spec/triggers
+    name: foo
+  spec:
+    replicas: 3
+    triggers: []`)
+
+	filter := &ResourceFilter{
+		Kinds: []string{"DeploymentConfig"},
+	}
+	changeset := getChangeset(t, filter, platformInput, templateInput, false, []string{})
+	if len(changeset.Update) != 0 {
+		for i, u := range changeset.Update {
+			t.Errorf("Patchset Update#%d: %s", i, u.JsonPatches(true))
+		}
+	}
+
+	invalidTemplateInput := bytes.Replace(templateInput, []byte("/spec/triggers"), []byte("spec/triggers"), -1)
+	platformBasedList, _ := NewPlatformBasedResourceList(filter, platformInput)
+	templateBasedList, _ := NewTemplateBasedResourceList(filter, invalidTemplateInput)
+	_, err := NewChangeset(platformBasedList, templateBasedList, false, []string{})
+	if err == nil {
+		t.Errorf("Expected invalid annotation to be reported")
+	}
+}
+
 func TestConfigCreation(t *testing.T) {
 	templateInput := []byte(
 		`kind: List
@@ -0,0 +1,97 @@
+package openshift
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/xeipuuv/gojsonpointer"
+)
+
+var (
+	// instanceSpecificFields lists fields per kind which are allocated by the
+	// cluster for one particular resource, and must not be copied.
+	instanceSpecificFields = map[string][]string{
+		"Service":               []string{"/spec/clusterIP"},
+		"PersistentVolumeClaim": []string{"/spec/volumeName"},
+	}
+)
+
+// NamespaceRewrite is a value which has been changed from referencing the
+// source namespace to referencing the target namespace.
+type NamespaceRewrite struct {
+	Item string
+	Path string
+	From string
+	To   string
+}
+
+// PrepareForClone removes fields which cannot be copied into another
+// namespace, and replaces references to namespace from with references to
+// namespace to. References are values equal to the namespace, or containing
+// it as a segment delimited by "/", "." or ":", as in image references
+// ("registry:5000/from/foo:latest"), service hostnames ("foo.from.svc") or
+// service account names ("system:serviceaccount:from:foo"). In route hosts,
+// "-" delimits segments as well ("foo-from.apps.example.com").
+// The resource name is never changed.
+func (i *ResourceItem) PrepareForClone(from string, to string) []*NamespaceRewrite {
+	i.removeInstanceSpecificFields()
+
+	segmentRegex := namespaceSegmentRegex(from, "/.:")
+	hostRegex := namespaceSegmentRegex(from, "/.:-")
+
+	paths := append([]string{}, i.Paths...)
+	sort.Strings(paths)
+	rewrites := []*NamespaceRewrite{}
+	for _, path := range paths {
+		if path == "/metadata/name" {
+			continue
+		}
+		pointer, _ := gojsonpointer.NewJsonPointer(path)
+		val, _, err := pointer.Get(i.Config)
+		if err != nil {
+			continue
+		}
+		s, ok := val.(string)
+		if !ok {
+			continue
+		}
+		re := segmentRegex
+		if i.Kind == "Route" && path == "/spec/host" {
+			re = hostRegex
+		}
+		rewritten := rewriteNamespaceSegments(s, re, to)
+		if rewritten == s {
+			continue
+		}
+		_, _ = pointer.Set(i.Config, rewritten)
+		rewrites = append(rewrites, &NamespaceRewrite{
+			Item: i.FullName(),
+			Path: path,
+			From: s,
+			To:   rewritten,
+		})
+	}
+	return rewrites
+}
+
+// removeInstanceSpecificFields removes the namespace and fields allocated by
+// the cluster for this particular resource.
+func (i *ResourceItem) removeInstanceSpecificFields() {
+	i.removePath("/metadata/namespace")
+	for _, f := range instanceSpecificFields[i.Kind] {
+		i.removePath(f)
+	}
+}
+
+// namespaceSegmentRegex matches namespace as a segment delimited by any of
+// the given delimiters or the start/end of the value.
+func namespaceSegmentRegex(namespace string, delimiters string) *regexp.Regexp {
+	d := regexp.QuoteMeta(delimiters)
+	return regexp.MustCompile(`(^|[` + d + `])` + regexp.QuoteMeta(namespace) + `($|[` + d + `])`)
+}
+
+// rewriteNamespaceSegments replaces all segments matched by re with to,
+// keeping the delimiters.
+func rewriteNamespaceSegments(value string, re *regexp.Regexp, to string) string {
+	return re.ReplaceAllString(value, "${1}"+to+"${2}")
+}
@@ -0,0 +1,161 @@
+package openshift
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+func TestPrepareForClone(t *testing.T) {
+	tests := map[string]struct {
+		input            string
+		expectedConfig   string
+		expectedRewrites []*NamespaceRewrite
+	}{
+		"image and service account references": {
+			input: `apiVersion: v1
+kind: DeploymentConfig
+metadata:
+  name: foo-dev
+  namespace: foo-dev
+spec:
+  template:
+    spec:
+      containers:
+      - image: docker-registry.default.svc:5000/foo-dev/app:latest
+        name: foo-dev-app
+        env:
+        - name: DB_HOST
+          value: db.foo-dev.svc
+      serviceAccountName: system:serviceaccount:foo-dev:builder
+  triggers:
+  - imageChangeParams:
+      from:
+        namespace: foo-dev
+`,
+			expectedConfig: `apiVersion: v1
+kind: DeploymentConfig
+metadata:
+  annotations:
+    original-values.tailor.io/spec.template.spec.containers.0.image: docker-registry.default.svc:5000/foo-feature/app:latest
+  name: foo-dev
+spec:
+  template:
+    spec:
+      containers:
+      - image: docker-registry.default.svc:5000/foo-feature/app:latest
+        name: foo-dev-app
+        env:
+        - name: DB_HOST
+          value: db.foo-feature.svc
+      serviceAccountName: system:serviceaccount:foo-feature:builder
+  triggers:
+  - imageChangeParams:
+      from:
+        namespace: foo-feature
+`,
+			expectedRewrites: []*NamespaceRewrite{
+				&NamespaceRewrite{
+					Item: "DeploymentConfig/foo-dev",
+					Path: "/metadata/annotations/original-values.tailor.io~1spec.template.spec.containers.0.image",
+					From: "docker-registry.default.svc:5000/foo-dev/app:latest",
+					To:   "docker-registry.default.svc:5000/foo-feature/app:latest",
+				},
+				&NamespaceRewrite{
+					Item: "DeploymentConfig/foo-dev",
+					Path: "/spec/template/spec/containers/0/env/0/value",
+					From: "db.foo-dev.svc",
+					To:   "db.foo-feature.svc",
+				},
+				&NamespaceRewrite{
+					Item: "DeploymentConfig/foo-dev",
+					Path: "/spec/template/spec/containers/0/image",
+					From: "docker-registry.default.svc:5000/foo-dev/app:latest",
+					To:   "docker-registry.default.svc:5000/foo-feature/app:latest",
+				},
+				&NamespaceRewrite{
+					Item: "DeploymentConfig/foo-dev",
+					Path: "/spec/template/spec/serviceAccountName",
+					From: "system:serviceaccount:foo-dev:builder",
+					To:   "system:serviceaccount:foo-feature:builder",
+				},
+				&NamespaceRewrite{
+					Item: "DeploymentConfig/foo-dev",
+					Path: "/spec/triggers/0/imageChangeParams/from/namespace",
+					From: "foo-dev",
+					To:   "foo-feature",
+				},
+			},
+		},
+		"route host": {
+			input: `apiVersion: v1
+kind: Route
+metadata:
+  name: app
+spec:
+  host: app-foo-dev.apps.example.com
+`,
+			expectedConfig: `apiVersion: v1
+kind: Route
+metadata:
+  annotations: {}
+  name: app
+spec:
+  host: app-foo-feature.apps.example.com
+`,
+			expectedRewrites: []*NamespaceRewrite{
+				&NamespaceRewrite{
+					Item: "Route/app",
+					Path: "/spec/host",
+					From: "app-foo-dev.apps.example.com",
+					To:   "app-foo-feature.apps.example.com",
+				},
+			},
+		},
+		"instance specific fields": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  clusterIP: 172.30.0.1
+  selector:
+    name: app-foo-dev
+`,
+			expectedConfig: `apiVersion: v1
+kind: Service
+metadata:
+  annotations: {}
+  name: app
+spec:
+  selector:
+    name: app-foo-dev
+`,
+			expectedRewrites: []*NamespaceRewrite{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			item := getItem(t, []byte(tc.input), "platform")
+			item.RemoveUnmanagedAnnotations()
+			rewrites := item.PrepareForClone("foo-dev", "foo-feature")
+			if !reflect.DeepEqual(rewrites, tc.expectedRewrites) {
+				for _, r := range rewrites {
+					t.Logf("%s %s: %s -> %s", r.Item, r.Path, r.From, r.To)
+				}
+				t.Errorf("Got other rewrites than expected")
+			}
+			expected := map[string]interface{}{}
+			err := yaml.Unmarshal([]byte(tc.expectedConfig), &expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(item.Config, expected) {
+				actual, _ := yaml.Marshal(item.Config)
+				t.Errorf("Got config:\n%s\nwant:\n%s", actual, tc.expectedConfig)
+			}
+		})
+	}
+}
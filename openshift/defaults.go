@@ -0,0 +1,145 @@
+package openshift
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/utils"
+	"github.com/xeipuuv/gojsonpointer"
+)
+
+type serverDefault struct {
+	Path  *regexp.Regexp
+	Value interface{}
+}
+
+var (
+	podSpecPrefix             = "^/spec/template/spec"
+	containerPrefix           = podSpecPrefix + "/(init)?[cC]ontainers/[0-9]+"
+	podTemplateServerDefaults = []serverDefault{
+		defaultValue(podSpecPrefix+"/dnsPolicy$", "ClusterFirst"),
+		defaultValue(podSpecPrefix+"/restartPolicy$", "Always"),
+		defaultValue(podSpecPrefix+"/schedulerName$", "default-scheduler"),
+		defaultValue(podSpecPrefix+"/securityContext$", map[string]interface{}{}),
+		defaultValue(podSpecPrefix+"/terminationGracePeriodSeconds$", float64(30)),
+		defaultValue(containerPrefix+"/terminationMessagePath$", "/dev/termination-log"),
+		defaultValue(containerPrefix+"/terminationMessagePolicy$", "File"),
+		defaultValue(containerPrefix+"/resources$", map[string]interface{}{}),
+		defaultValue(containerPrefix+"/ports/[0-9]+/protocol$", "TCP"),
+		defaultValue(containerPrefix+"/(liveness|readiness)Probe/failureThreshold$", float64(3)),
+		defaultValue(containerPrefix+"/(liveness|readiness)Probe/periodSeconds$", float64(10)),
+		defaultValue(containerPrefix+"/(liveness|readiness)Probe/successThreshold$", float64(1)),
+		defaultValue(containerPrefix+"/(liveness|readiness)Probe/timeoutSeconds$", float64(1)),
+	}
+	// serverDefaults lists per kind which fields are populated by the server
+	// if they are not given.
+	serverDefaults = map[string][]serverDefault{
+		"DeploymentConfig": append([]serverDefault{
+			defaultValue("^/spec/test$", false),
+			defaultValue("^/spec/strategy/activeDeadlineSeconds$", float64(21600)),
+			defaultValue("^/spec/strategy/resources$", map[string]interface{}{}),
+			defaultValue("^/spec/strategy/(rolling|recreate)Params/timeoutSeconds$", float64(600)),
+			defaultValue("^/spec/strategy/rollingParams/intervalSeconds$", float64(1)),
+			defaultValue("^/spec/strategy/rollingParams/updatePeriodSeconds$", float64(1)),
+			defaultValue("^/spec/strategy/rollingParams/maxSurge$", "25%"),
+			defaultValue("^/spec/strategy/rollingParams/maxUnavailable$", "25%"),
+		}, podTemplateServerDefaults...),
+		"BuildConfig": []serverDefault{
+			defaultValue("^/spec/runPolicy$", "Serial"),
+			defaultValue("^/spec/postCommit$", map[string]interface{}{}),
+			defaultValue("^/spec/resources$", map[string]interface{}{}),
+			defaultValue("^/spec/nodeSelector$", nil),
+			defaultValue("^/spec/successfulBuildsHistoryLimit$", float64(5)),
+			defaultValue("^/spec/failedBuildsHistoryLimit$", float64(5)),
+		},
+		"Service": []serverDefault{
+			defaultValue("^/spec/sessionAffinity$", "None"),
+			defaultValue("^/spec/type$", "ClusterIP"),
+			defaultValue("^/spec/ports/[0-9]+/protocol$", "TCP"),
+		},
+		"Route": []serverDefault{
+			defaultValue("^/spec/wildcardPolicy$", "None"),
+			defaultValue("^/spec/to/weight$", float64(100)),
+		},
+		"ImageStream": []serverDefault{
+			defaultValue("^/spec/lookupPolicy/local$", false),
+		},
+		"PersistentVolumeClaim": []serverDefault{
+			defaultValue("^/spec/volumeMode$", "Filesystem"),
+		},
+	}
+)
+
+func defaultValue(pattern string, value interface{}) serverDefault {
+	return serverDefault{Path: regexp.MustCompile(pattern), Value: value}
+}
+
+// RemoveServerDefaults removes all fields which are set to the value the
+// server would populate them with anyway. Maps which become empty through
+// this are removed as well.
+func (i *ResourceItem) RemoveServerDefaults() {
+	defaults, ok := serverDefaults[i.Kind]
+	if !ok {
+		return
+	}
+
+	// Start with the deepest paths so that parents emptied by the removal
+	// of their children can be detected.
+	paths := append([]string{}, i.Paths...)
+	sort.Slice(paths, func(a, b int) bool {
+		return strings.Count(paths[a], "/") > strings.Count(paths[b], "/")
+	})
+
+	for _, path := range paths {
+		for _, d := range defaults {
+			if !d.Path.MatchString(path) {
+				continue
+			}
+			pointer, _ := gojsonpointer.NewJsonPointer(path)
+			val, _, err := pointer.Get(i.Config)
+			if err != nil || !reflect.DeepEqual(val, d.Value) {
+				continue
+			}
+			cli.DebugMsg("Removing server default", path, "from", i.FullName())
+			i.removePath(path)
+			i.removeEmptyParents(path)
+			break
+		}
+	}
+}
+
+// removePath deletes path from the config and removes it (and its subpaths)
+// from the list of paths.
+func (i *ResourceItem) removePath(path string) {
+	pointer, _ := gojsonpointer.NewJsonPointer(path)
+	_, _ = pointer.Delete(i.Config)
+	i.removeSubpaths(path)
+	i.Paths = utils.Remove(i.Paths, path)
+}
+
+func (i *ResourceItem) removeEmptyParents(path string) {
+	for {
+		lastSlash := strings.LastIndex(path, "/")
+		if lastSlash < 1 {
+			return
+		}
+		path = path[:lastSlash]
+		for _, f := range emptyMapFields {
+			if f == path {
+				return
+			}
+		}
+		pointer, _ := gojsonpointer.NewJsonPointer(path)
+		val, _, err := pointer.Get(i.Config)
+		if err != nil {
+			return
+		}
+		if m, ok := val.(map[string]interface{}); !ok || len(m) > 0 {
+			return
+		}
+		i.removePath(path)
+	}
+}
@@ -0,0 +1,126 @@
+package openshift
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemoveServerDefaults(t *testing.T) {
+	item := getItem(t, []byte(
+		`apiVersion: v1
+kind: DeploymentConfig
+metadata:
+  name: foo
+spec:
+  replicas: 1
+  strategy:
+    activeDeadlineSeconds: 21600
+    resources: {}
+    rollingParams:
+      intervalSeconds: 1
+      maxSurge: 25%
+      maxUnavailable: 25%
+      timeoutSeconds: 600
+      updatePeriodSeconds: 1
+    type: Rolling
+  template:
+    spec:
+      containers:
+      - image: foo:latest
+        name: foo
+        ports:
+        - containerPort: 8080
+          protocol: TCP
+        resources: {}
+        terminationMessagePath: /dev/termination-log
+        terminationMessagePolicy: File
+      dnsPolicy: ClusterFirst
+      restartPolicy: Never
+      schedulerName: default-scheduler
+      securityContext: {}
+      terminationGracePeriodSeconds: 60
+  test: false`), "platform")
+
+	item.RemoveServerDefaults()
+
+	expectedSpec := map[string]interface{}{
+		"replicas": float64(1),
+		"strategy": map[string]interface{}{
+			"type": "Rolling",
+		},
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{
+						"image": "foo:latest",
+						"name":  "foo",
+						"ports": []interface{}{
+							map[string]interface{}{
+								"containerPort": float64(8080),
+							},
+						},
+					},
+				},
+				"restartPolicy":                 "Never",
+				"terminationGracePeriodSeconds": float64(60),
+			},
+		},
+	}
+	if !reflect.DeepEqual(item.Config["spec"], expectedSpec) {
+		t.Errorf("Got %v, want %v", item.Config["spec"], expectedSpec)
+	}
+	for _, p := range item.Paths {
+		if p == "/spec/strategy/rollingParams" || p == "/spec/test" {
+			t.Errorf("Path %s should have been removed", p)
+		}
+	}
+}
+
+func TestChangesetIgnoringServerDefaults(t *testing.T) {
+	templateInput := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: foo
+  spec:
+    ports:
+    - port: 8080`)
+
+	platformInput := []byte(
+		`kind: Template
+apiVersion: v1
+objects:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: foo
+  spec:
+    ports:
+    - port: 8080
+      protocol: TCP
+    sessionAffinity: None
+    type: ClusterIP`)
+
+	filter := &ResourceFilter{
+		Kinds: []string{"Service"},
+	}
+	changeset := getChangeset(t, filter, platformInput, templateInput, false, []string{})
+	if changeset.Blank() {
+		t.Errorf("Changeset should have drift without ignoring defaults")
+	}
+
+	platformBasedList, _ := NewPlatformBasedResourceList(filter, platformInput)
+	templateBasedList, _ := NewTemplateBasedResourceList(filter, templateInput)
+	platformBasedList.RemoveServerDefaults()
+	templateBasedList.RemoveServerDefaults()
+	changeset, err := NewChangeset(platformBasedList, templateBasedList, false, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changeset.Blank() {
+		t.Errorf("Changeset should be blank when ignoring defaults, got %s", changeset.Update[0].JsonPatches(true))
+	}
+}
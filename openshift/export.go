@@ -0,0 +1,323 @@
+package openshift
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	unsafeFilenameChars = regexp.MustCompile("[^a-z0-9._-]+")
+	// Filename used for items which do not carry the split label
+	unlabelledTemplateName = "common"
+	tailorNamespaceParam   = "TAILOR_NAMESPACE"
+	paramNamePattern       = regexp.MustCompile("^[a-zA-Z0-9_]+$")
+	nonParamNameChars      = regexp.MustCompile("[^A-Z0-9_]+")
+)
+
+// ExportedTemplate is a template assembled from exported items, which is
+// meant to be written to the file given by Filename.
+type ExportedTemplate struct {
+	Filename         string
+	Items            []*ResourceItem
+	Parameters       map[string]string
+	SecretParameters map[string]string
+}
+
+// Config returns the template as a map, ready to be marshalled. Items are
+// ordered by kind (in the same order as they are created) and name.
+func (t *ExportedTemplate) Config() map[string]interface{} {
+	objects := []interface{}{}
+	for _, item := range t.Items {
+		objects = append(objects, item.Config)
+	}
+	config := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Template",
+		"objects":    objects,
+	}
+	if len(t.Parameters) > 0 || len(t.SecretParameters) > 0 {
+		parameters := []interface{}{}
+		for _, name := range t.parameterNames() {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     name,
+				"required": true,
+			})
+		}
+		config["parameters"] = parameters
+	}
+	return config
+}
+
+// ParamFilename returns the name of the param file belonging to the template.
+func (t *ExportedTemplate) ParamFilename() string {
+	return strings.TrimSuffix(t.Filename, ".yml") + ".env"
+}
+
+// HasParamFile returns true if the template needs a param file. Secret
+// parameters are written into the encrypted param file, which is read on its
+// own, so they do not need one.
+func (t *ExportedTemplate) HasParamFile() bool {
+	return len(t.ParamFileContent()) > 0
+}
+
+// ParamFileContent returns the content of the param file belonging to the
+// template. TAILOR_NAMESPACE is omitted as Tailor sets it automatically.
+func (t *ExportedTemplate) ParamFileContent() string {
+	var b strings.Builder
+	for _, name := range sortedKeys(t.Parameters) {
+		if name == tailorNamespaceParam {
+			continue
+		}
+		b.WriteString(name + "=" + t.Parameters[name] + "\n")
+	}
+	return b.String()
+}
+
+// SecretParamFileContent returns the cleartext content of the encrypted param
+// file belonging to the template. Values are already base64-encoded, which
+// is indicated by the ".B64" key suffix.
+func (t *ExportedTemplate) SecretParamFileContent() string {
+	var b strings.Builder
+	for _, name := range sortedKeys(t.SecretParameters) {
+		b.WriteString(name + ".B64=" + t.SecretParameters[name] + "\n")
+	}
+	return b.String()
+}
+
+// ExtractSecrets replaces the data values of all secrets with a reference to
+// a parameter named after the secret and the data key, e.g. the key
+// "password" of secret "foo-db" is replaced by ${FOO_DB_PASSWORD}.
+func (t *ExportedTemplate) ExtractSecrets() {
+	if t.SecretParameters == nil {
+		t.SecretParameters = map[string]string{}
+	}
+	for _, item := range t.Items {
+		if item.Kind != "Secret" {
+			continue
+		}
+		data, ok := item.Config["data"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		keys := []string{}
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			val, ok := data[k].(string)
+			if !ok {
+				continue
+			}
+			name := t.uniqueParamName(secretParamName(item.Name, k))
+			t.SecretParameters[name] = val
+			data[k] = "${" + name + "}"
+		}
+	}
+}
+
+func (t *ExportedTemplate) uniqueParamName(name string) string {
+	candidate := name
+	for i := 2; ; i++ {
+		_, isParam := t.Parameters[candidate]
+		_, isSecretParam := t.SecretParameters[candidate]
+		if !isParam && !isSecretParam {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+}
+
+func secretParamName(secretName string, key string) string {
+	name := strings.ToUpper(secretName + "_" + key)
+	return nonParamNameChars.ReplaceAllString(name, "_")
+}
+
+// Parameterize replaces string values equal to the namespace with a
+// reference to TAILOR_NAMESPACE, and values matching one of the given rules
+// (mapping values to parameter names) with a reference to that parameter.
+// Rules starting with "/" are JSON paths within each item (e.g.
+// /spec/replicas), and replace the value at that path whatever its type.
+// Non-string values are referenced as ${{NAME}} so that OpenShift keeps their
+// type when processing the template. Other rules replace string values which
+// are exactly equal. The name of an item is never replaced.
+func (t *ExportedTemplate) Parameterize(namespace string, rules map[string]string) {
+	if t.Parameters == nil {
+		t.Parameters = map[string]string{}
+	}
+	for _, item := range t.Items {
+		t.parameterizeMap(item.Config, "", namespace, rules)
+	}
+}
+
+func (t *ExportedTemplate) parameterizeMap(m map[string]interface{}, path string, namespace string, rules map[string]string) {
+	for k, v := range m {
+		m[k] = t.parameterizeValue(v, path+"/"+k, namespace, rules)
+	}
+}
+
+func (t *ExportedTemplate) parameterizeValue(v interface{}, path string, namespace string, rules map[string]string) interface{} {
+	if path == "/metadata/name" {
+		return v
+	}
+	if name, ok := rules[path]; ok {
+		switch vv := v.(type) {
+		case string:
+			name = t.pathParamName(name, vv)
+			t.Parameters[name] = vv
+			return "${" + name + "}"
+		case bool, float64:
+			strVal := fmt.Sprintf("%v", vv)
+			name = t.pathParamName(name, strVal)
+			t.Parameters[name] = strVal
+			return "${{" + name + "}}"
+		}
+	}
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		t.parameterizeMap(vv, path, namespace, rules)
+		return vv
+	case []interface{}:
+		for i, e := range vv {
+			vv[i] = t.parameterizeValue(e, path+"/"+strconv.Itoa(i), namespace, rules)
+		}
+		return vv
+	case string:
+		if len(namespace) > 0 && vv == namespace {
+			t.Parameters[tailorNamespaceParam] = vv
+			return "${" + tailorNamespaceParam + "}"
+		}
+		if name, ok := rules[vv]; ok && !strings.HasPrefix(vv, "/") {
+			t.Parameters[name] = vv
+			return "${" + name + "}"
+		}
+	}
+	return v
+}
+
+// pathParamName returns the name of the parameter for given value found at a
+// path matching a rule. If items of the template have different values at
+// that path, each value gets its own parameter, e.g. REPLICAS and REPLICAS_2.
+func (t *ExportedTemplate) pathParamName(name string, value string) string {
+	candidate := name
+	for i := 2; ; i++ {
+		existing, ok := t.Parameters[candidate]
+		if !ok || existing == value {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+}
+
+func (t *ExportedTemplate) parameterNames() []string {
+	names := append(sortedKeys(t.Parameters), sortedKeys(t.SecretParameters)...)
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ParseParamRules parses rules of the form VALUE=NAME or PATH=NAME, where
+// PATH starts with "/". As values might contain "=" themselves, the last "="
+// separates value and name.
+func ParseParamRules(rules []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, rule := range rules {
+		i := strings.LastIndex(rule, "=")
+		if i < 1 || i == len(rule)-1 {
+			return nil, fmt.Errorf("%s is not a valid parameter rule, must be VALUE=NAME or PATH=NAME", rule)
+		}
+		value := rule[:i]
+		name := rule[i+1:]
+		if !paramNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("%s is not a valid parameter name", name)
+		}
+		parsed[value] = name
+	}
+	return parsed, nil
+}
+
+// SplitExportedItems groups items into templates. splitBy is either "kind",
+// which results in one template per kind, or "label", which results in one
+// template per value of splitLabel. Templates are sorted by filename.
+func SplitExportedItems(items []*ResourceItem, splitBy string, splitLabel string) ([]*ExportedTemplate, error) {
+	templates := map[string]*ExportedTemplate{}
+	for _, item := range items {
+		var name string
+		switch splitBy {
+		case "kind":
+			name = kindToShortMapping[item.Kind]
+			if len(name) == 0 {
+				name = item.Kind
+			}
+		case "label":
+			name = unlabelledTemplateName
+			if val, ok := item.Labels[splitLabel]; ok {
+				name = fmt.Sprintf("%v", val)
+			}
+		default:
+			return nil, fmt.Errorf("Cannot split by '%s', must be either kind or label", splitBy)
+		}
+		filename := sanitizeFilename(name) + ".yml"
+		if _, ok := templates[filename]; !ok {
+			templates[filename] = &ExportedTemplate{Filename: filename}
+		}
+		templates[filename].Items = append(templates[filename].Items, item)
+	}
+
+	exportedTemplates := []*ExportedTemplate{}
+	for _, t := range templates {
+		sortItems(t.Items)
+		exportedTemplates = append(exportedTemplates, t)
+	}
+	sort.Slice(exportedTemplates, func(i, j int) bool {
+		return exportedTemplates[i].Filename < exportedTemplates[j].Filename
+	})
+	return exportedTemplates, nil
+}
+
+// ExportIndex returns a human-readable overview of which template contains
+// which items.
+func ExportIndex(templates []*ExportedTemplate) string {
+	var b strings.Builder
+	b.WriteString("# Exported templates\n")
+	for _, t := range templates {
+		b.WriteString("\n## " + t.Filename + "\n\n")
+		for _, item := range t.Items {
+			b.WriteString("* " + item.FullName() + "\n")
+		}
+	}
+	return b.String()
+}
+
+func sortItems(items []*ResourceItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			if kindOrder[items[i].Kind] != kindOrder[items[j].Kind] {
+				return kindOrder[items[i].Kind] < kindOrder[items[j].Kind]
+			}
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].Name < items[j].Name
+	})
+}
+
+func sanitizeFilename(name string) string {
+	name = strings.ToLower(name)
+	name = unsafeFilenameChars.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-.")
+	if len(name) == 0 {
+		return unlabelledTemplateName
+	}
+	return name
+}
@@ -0,0 +1,226 @@
+package openshift
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitExportedItems(t *testing.T) {
+	bc := getItem(t, getBuildConfig(), "platform")
+	route := getItem(t, getRoute([]byte("foo.com")), "platform")
+	cm := getItem(t, getConfigMap([]byte("{}")), "platform")
+	items := []*ResourceItem{route, bc, cm}
+
+	tests := map[string]struct {
+		splitBy  string
+		expected map[string][]string
+	}{
+		"by kind": {
+			splitBy: "kind",
+			expected: map[string][]string{
+				"bc.yml":    []string{"BuildConfig/foo"},
+				"cm.yml":    []string{"ConfigMap/bar"},
+				"route.yml": []string{"Route/foo"},
+			},
+		},
+		"by label": {
+			splitBy: "label",
+			expected: map[string][]string{
+				"bar.yml":    []string{"ConfigMap/bar"},
+				"common.yml": []string{"Route/foo"},
+				"foo.yml":    []string{"BuildConfig/foo"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			templates, err := SplitExportedItems(items, tc.splitBy, "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := map[string][]string{}
+			filenames := []string{}
+			for _, tpl := range templates {
+				filenames = append(filenames, tpl.Filename)
+				for _, item := range tpl.Items {
+					actual[tpl.Filename] = append(actual[tpl.Filename], item.FullName())
+				}
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Got %v, want %v", actual, tc.expected)
+			}
+			for i := 1; i < len(filenames); i++ {
+				if filenames[i-1] > filenames[i] {
+					t.Errorf("Templates are not sorted by filename: %v", filenames)
+				}
+			}
+		})
+	}
+
+	_, err := SplitExportedItems(items, "foo", "app")
+	if err == nil {
+		t.Errorf("Expected error for unknown split criteria")
+	}
+}
+
+func TestSplitExportedItemsOrder(t *testing.T) {
+	cmBar := getItem(t, getConfigMap([]byte("{}")), "platform")
+	cmFoo := getItem(t, getConfigMap([]byte("{}")), "platform")
+	cmFoo.Name = "foo"
+	bc := getItem(t, getBuildConfig(), "platform")
+	bc.Labels["app"] = "bar"
+	templates, err := SplitExportedItems([]*ResourceItem{bc, cmFoo, cmBar}, "label", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := []string{}
+	for _, item := range templates[0].Items {
+		actual = append(actual, item.FullName())
+	}
+	expected := []string{"ConfigMap/bar", "ConfigMap/foo", "BuildConfig/foo"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %v, want %v", actual, expected)
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := map[string]string{
+		"foo":         "foo",
+		"Foo Bar":     "foo-bar",
+		"../etc/pass": "etc-pass",
+		"":            "common",
+	}
+	for input, expected := range tests {
+		actual := sanitizeFilename(input)
+		if actual != expected {
+			t.Errorf("Got %s for %s, want %s", actual, input, expected)
+		}
+	}
+}
+
+func TestParameterize(t *testing.T) {
+	route := getItem(t, getRoute([]byte("foo.example.com")), "platform")
+	dc := getItem(t, getTemplateDeploymentConfig([]byte("latest")), "platform")
+	otherDc := getItem(t, getTemplateDeploymentConfig([]byte("latest")), "platform")
+	otherDc.Config["metadata"].(map[string]interface{})["name"] = "bar"
+	otherDc.Config["spec"].(map[string]interface{})["replicas"] = float64(2)
+	cm := getItem(t, getConfigMap([]byte("{}")), "platform")
+	cm.Config["data"] = map[string]interface{}{"namespace": "foo-dev"}
+	cm.Config["metadata"].(map[string]interface{})["name"] = "foo-dev"
+	tpl := &ExportedTemplate{Filename: "foo.yml", Items: []*ResourceItem{route, dc, otherDc, cm}}
+
+	rules, err := ParseParamRules([]string{
+		"foo.example.com=ROUTE_HOST",
+		"bar/foo:latest=IMAGE",
+		"/spec/replicas=REPLICAS",
+		"100=WEIGHT",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.Parameterize("foo-dev", rules)
+
+	if host := route.Config["spec"].(map[string]interface{})["host"]; host != "${ROUTE_HOST}" {
+		t.Errorf("Got host %v, want ${ROUTE_HOST}", host)
+	}
+	if weight := route.Config["spec"].(map[string]interface{})["to"].(map[string]interface{})["weight"]; weight != float64(100) {
+		t.Errorf("Value rules should only replace string values, got weight %v", weight)
+	}
+	containers := dc.Config["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})
+	if image := containers[0].(map[string]interface{})["image"]; image != "${IMAGE}" {
+		t.Errorf("Got image %v, want ${IMAGE}", image)
+	}
+	if replicas := dc.Config["spec"].(map[string]interface{})["replicas"]; replicas != "${{REPLICAS}}" {
+		t.Errorf("Got replicas %v, want ${{REPLICAS}}", replicas)
+	}
+	if replicas := otherDc.Config["spec"].(map[string]interface{})["replicas"]; replicas != "${{REPLICAS_2}}" {
+		t.Errorf("Got replicas %v, want ${{REPLICAS_2}}", replicas)
+	}
+	if ns := cm.Config["data"].(map[string]interface{})["namespace"]; ns != "${TAILOR_NAMESPACE}" {
+		t.Errorf("Got namespace %v, want ${TAILOR_NAMESPACE}", ns)
+	}
+	if name := cm.Config["metadata"].(map[string]interface{})["name"]; name != "foo-dev" {
+		t.Errorf("Item names should not be replaced, got %v", name)
+	}
+
+	expectedParams := []interface{}{
+		map[string]interface{}{"name": "IMAGE", "required": true},
+		map[string]interface{}{"name": "REPLICAS", "required": true},
+		map[string]interface{}{"name": "REPLICAS_2", "required": true},
+		map[string]interface{}{"name": "ROUTE_HOST", "required": true},
+		map[string]interface{}{"name": "TAILOR_NAMESPACE", "required": true},
+	}
+	if actual := tpl.Config()["parameters"]; !reflect.DeepEqual(actual, expectedParams) {
+		t.Errorf("Got parameters %v, want %v", actual, expectedParams)
+	}
+
+	expectedContent := "IMAGE=bar/foo:latest\nREPLICAS=1\nREPLICAS_2=2\nROUTE_HOST=foo.example.com\n"
+	if actual := tpl.ParamFileContent(); actual != expectedContent {
+		t.Errorf("Got param file content %q, want %q", actual, expectedContent)
+	}
+	if actual := tpl.ParamFilename(); actual != "foo.env" {
+		t.Errorf("Got param filename %s, want foo.env", actual)
+	}
+}
+
+func TestParseParamRules(t *testing.T) {
+	actual, err := ParseParamRules([]string{"a=b=FOO"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"a=b": "FOO"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %v, want %v", actual, expected)
+	}
+
+	for _, invalid := range []string{"foo", "=FOO", "foo=", "foo=BA R"} {
+		if _, err := ParseParamRules([]string{invalid}); err == nil {
+			t.Errorf("Expected %s to be invalid", invalid)
+		}
+	}
+}
+
+func TestExtractSecrets(t *testing.T) {
+	secret := getItem(t, []byte(
+		`apiVersion: v1
+kind: Secret
+metadata:
+  name: foo-db
+data:
+  password: c2VjcmV0
+  user.name: Zm9v
+type: Opaque`), "platform")
+	tpl := &ExportedTemplate{Filename: "foo.yml", Items: []*ResourceItem{secret}}
+	tpl.ExtractSecrets()
+
+	expectedData := map[string]interface{}{
+		"password":  "${FOO_DB_PASSWORD}",
+		"user.name": "${FOO_DB_USER_NAME}",
+	}
+	if actual := secret.Config["data"]; !reflect.DeepEqual(actual, expectedData) {
+		t.Errorf("Got data %v, want %v", actual, expectedData)
+	}
+	if tpl.HasParamFile() {
+		t.Errorf("Template with only secret parameters should not need a param file")
+	}
+
+	cleartext := tpl.SecretParamFileContent()
+	expectedCleartext := "FOO_DB_PASSWORD.B64=c2VjcmV0\nFOO_DB_USER_NAME.B64=Zm9v\n"
+	if cleartext != expectedCleartext {
+		t.Errorf("Got secret param content %q, want %q", cleartext, expectedCleartext)
+	}
+
+	encrypted, err := EncryptedParams(cleartext, "", ".", "test-private.key", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := EncodedParams(encrypted, "test-private.key", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedEncoded := "FOO_DB_PASSWORD=c2VjcmV0\nFOO_DB_USER_NAME=Zm9v\n"
+	if encoded != expectedEncoded {
+		t.Errorf("Got encoded params %q, want %q", encoded, expectedEncoded)
+	}
+}
@@ -3,6 +3,8 @@ package openshift
 import (
 	"errors"
 	"fmt"
+	"path"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -26,40 +28,61 @@ var availableKinds = []string{
 type ResourceFilter struct {
 	Kinds          []string
 	Name           string
+	Names          []string
 	Label          string
+	Annotations    []string
 	ExcludedKinds  []string
 	ExcludedNames  []string
 	ExcludedLabels []string
+	// ExcludedAnnotations are requirements without "annotation:" prefix.
+	ExcludedAnnotations []string
 }
 
 // NewResourceFilter returns a filter based on kinds and flags.
-// kindArg might be blank, or a list of kinds (e.g. 'pvc,dc') or
-// a kind/name combination (e.g. 'dc/foo').
-// selectorFlag might be blank or a key and a label, e.g. 'name=foo'.
+// kindArg might be blank, or a list of kinds (e.g. 'pvc,dc') or a list of
+// kind/name combinations (e.g. 'dc/foo,svc/foo'). Names may be globs
+// (e.g. 'dc/foo-*') or regular expressions prefixed with ~ (e.g. 'dc/~^foo-').
+// Kinds are case-insensitive, names are not.
+// selectorFlag might be blank or a label selector in Kubernetes syntax, e.g.
+// 'name=foo,tier!=frontend,env in (test,prod),!canary'. Requirements
+// prefixed with 'annotation:' (e.g. 'annotation:owner=platform-team') apply
+// to annotations instead of labels.
 func NewResourceFilter(kindArg string, selectorFlag string, excludeFlag string) (*ResourceFilter, error) {
 	filter := &ResourceFilter{
 		Kinds: []string{},
 		Name:  "",
-		Label: selectorFlag,
+		Label: LabelSelector(selectorFlag),
 	}
 
-	if len(kindArg) > 0 {
-		kindArg = strings.ToLower(kindArg)
+	for _, part := range splitSelector(selectorFlag) {
+		if isAnnotationRequirement(part) {
+			annotation := trimAnnotationPrefix(part)
+			if _, err := parseAnnotationRequirement(annotation); err != nil {
+				return nil, err
+			}
+			filter.Annotations = append(filter.Annotations, annotation)
+		}
+	}
 
+	if len(filter.Label) > 0 {
+		if _, err := parseLabelSelector(filter.Label); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(kindArg) > 0 {
+		// Names are passed as-is, as they might be case-sensitive patterns.
 		if strings.Contains(kindArg, "/") {
-			if strings.Contains(kindArg, ",") {
-				return nil, errors.New(
-					"You cannot target more than one resource name",
-				)
+			err := filter.setNames(strings.Split(kindArg, ","))
+			if err != nil {
+				return nil, err
 			}
-			nameParts := strings.Split(kindArg, "/")
-			filter.Name = KindMapping[nameParts[0]] + "/" + nameParts[1]
-			return filter, nil
+			return filter, filter.setExcludes(excludeFlag)
 		}
 
 		targetedKinds := make(map[string]bool)
 		unknownKinds := []string{}
-		kinds := strings.Split(kindArg, ",")
+		kinds := strings.Split(strings.ToLower(kindArg), ",")
 		for _, kind := range kinds {
 			if _, ok := KindMapping[kind]; !ok {
 				unknownKinds = append(unknownKinds, kind)
@@ -82,22 +105,91 @@ func NewResourceFilter(kindArg string, selectorFlag string, excludeFlag string)
 		sort.Strings(filter.Kinds)
 	}
 
+	return filter, filter.setExcludes(excludeFlag)
+}
+
+// setNames sets the targeted kind/name combinations. A single name without
+// pattern is kept in Name, otherwise the names are kept in Names and their
+// kinds in Kinds.
+func (filter *ResourceFilter) setNames(names []string) error {
+	targetedKinds := make(map[string]bool)
+	unknownKinds := []string{}
+	for _, v := range names {
+		v = strings.TrimSpace(v)
+		if !strings.Contains(v, "/") {
+			return errors.New(
+				"You cannot mix kinds and resource names, use e.g. dc/foo,svc/foo",
+			)
+		}
+		nameParts := strings.SplitN(v, "/", 2)
+		nameParts[0] = strings.ToLower(nameParts[0])
+		kind, ok := KindMapping[nameParts[0]]
+		if !ok {
+			unknownKinds = append(unknownKinds, nameParts[0])
+			continue
+		}
+		if err := validateNamePattern(nameParts[1]); err != nil {
+			return err
+		}
+		targetedKinds[kind] = true
+		filter.Names = append(filter.Names, kind+"/"+nameParts[1])
+	}
+
+	if len(unknownKinds) > 0 {
+		return fmt.Errorf(
+			"Unknown resource kinds: %s",
+			strings.Join(unknownKinds, ","),
+		)
+	}
+
+	if len(filter.Names) == 1 && !isNamePattern(filter.Names[0]) {
+		filter.Name = filter.Names[0]
+		filter.Names = nil
+		return nil
+	}
+
+	for kind := range targetedKinds {
+		filter.Kinds = append(filter.Kinds, kind)
+	}
+	sort.Strings(filter.Kinds)
+	return nil
+}
+
+func (filter *ResourceFilter) setExcludes(excludeFlag string) error {
 	if len(excludeFlag) > 0 {
 		unknownKinds := []string{}
-		excludes := strings.Split(excludeFlag, ",")
+		excludes := splitSelector(excludeFlag)
 		for _, v := range excludes {
-			v = strings.ToLower(v)
-			if strings.Contains(v, "/") { // Name
-				nameParts := strings.Split(v, "/")
-				k := nameParts[0]
+			if isAnnotationRequirement(v) { // Annotation
+				annotation := trimAnnotationPrefix(v)
+				if _, err := parseAnnotationRequirement(annotation); err != nil {
+					return err
+				}
+				filter.ExcludedAnnotations = append(filter.ExcludedAnnotations, annotation)
+				continue
+			}
+			v = strings.TrimSpace(v)
+			if isLabelRequirement(strings.ToLower(v)) { // Label
+				v = strings.ToLower(v)
+				if _, err := parseLabelRequirement(v); err != nil {
+					return err
+				}
+				filter.ExcludedLabels = append(filter.ExcludedLabels, v)
+			} else if strings.Contains(v, "/") { // Name
+				// Only the kind is lowercased, as names might be
+				// case-sensitive patterns.
+				nameParts := strings.SplitN(v, "/", 2)
+				k := strings.ToLower(nameParts[0])
 				if _, ok := KindMapping[k]; !ok {
 					unknownKinds = append(unknownKinds, k)
 				} else {
+					if err := validateNamePattern(nameParts[1]); err != nil {
+						return err
+					}
 					filter.ExcludedNames = append(filter.ExcludedNames, KindMapping[k]+"/"+nameParts[1])
 				}
-			} else if strings.Contains(v, "=") { // Label
-				filter.ExcludedLabels = append(filter.ExcludedLabels, v)
 			} else { // Kind
+				v = strings.ToLower(v)
 				if _, ok := KindMapping[v]; !ok {
 					unknownKinds = append(unknownKinds, v)
 				} else {
@@ -107,18 +199,18 @@ func NewResourceFilter(kindArg string, selectorFlag string, excludeFlag string)
 		}
 
 		if len(unknownKinds) > 0 {
-			return nil, fmt.Errorf(
+			return fmt.Errorf(
 				"Unknown excluded resource kinds: %s",
 				strings.Join(unknownKinds, ","),
 			)
 		}
 	}
 
-	return filter, nil
+	return nil
 }
 
 func (f *ResourceFilter) String() string {
-	return fmt.Sprintf("Kinds: %s, Name: %s, Label: %s, ExcludedKinds: %s, ExcludedNames: %s, ExcludedLabels: %s", f.Kinds, f.Name, f.Label, f.ExcludedKinds, f.ExcludedNames, f.ExcludedLabels)
+	return fmt.Sprintf("Kinds: %s, Name: %s, Names: %s, Label: %s, Annotations: %s, ExcludedKinds: %s, ExcludedNames: %s, ExcludedLabels: %s, ExcludedAnnotations: %s", f.Kinds, f.Name, f.Names, f.Label, f.Annotations, f.ExcludedKinds, f.ExcludedNames, f.ExcludedLabels, f.ExcludedAnnotations)
 }
 
 func (f *ResourceFilter) SatisfiedBy(item *ResourceItem) bool {
@@ -126,21 +218,29 @@ func (f *ResourceFilter) SatisfiedBy(item *ResourceItem) bool {
 		return false
 	}
 
+	if len(f.Names) > 0 && !matchesAnyName(f.Names, item) {
+		return false
+	}
+
 	if len(f.Kinds) > 0 && !utils.Includes(f.Kinds, item.Kind) {
 		return false
 	}
 
 	if len(f.Label) > 0 {
-		labels := strings.Split(f.Label, ",")
-		for _, label := range labels {
-			if !item.HasLabel(label) {
-				return false
-			}
+		matches, err := item.MatchesSelector(f.Label)
+		if err != nil || !matches {
+			return false
+		}
+	}
+
+	for _, annotation := range f.Annotations {
+		if !item.HasAnnotation(annotation) {
+			return false
 		}
 	}
 
 	if len(f.ExcludedNames) > 0 {
-		if utils.Includes(f.ExcludedNames, item.FullName()) {
+		if matchesAnyName(f.ExcludedNames, item) {
 			return false
 		}
 	}
@@ -159,9 +259,62 @@ func (f *ResourceFilter) SatisfiedBy(item *ResourceItem) bool {
 		}
 	}
 
+	for _, ea := range f.ExcludedAnnotations {
+		if item.HasAnnotation(ea) {
+			return false
+		}
+	}
+
 	return true
 }
 
+// isNamePattern returns true if the name of the kind/name combination is a
+// glob or a regular expression.
+func isNamePattern(kindName string) bool {
+	name := kindName[strings.Index(kindName, "/")+1:]
+	return strings.HasPrefix(name, "~") || strings.ContainsAny(name, "*?[")
+}
+
+func validateNamePattern(name string) error {
+	if strings.HasPrefix(name, "~") {
+		if _, err := regexp.Compile(name[1:]); err != nil {
+			return fmt.Errorf("Invalid name pattern %s: %s", name, err)
+		}
+		return nil
+	}
+	if _, err := path.Match(name, ""); err != nil {
+		return fmt.Errorf("Invalid name pattern %s: %s", name, err)
+	}
+	return nil
+}
+
+// matchesAnyName returns true if item matches one of the given kind/name
+// combinations, whose names may be globs or regular expressions.
+func matchesAnyName(kindNames []string, item *ResourceItem) bool {
+	for _, kindName := range kindNames {
+		nameParts := strings.SplitN(kindName, "/", 2)
+		if nameParts[0] != item.Kind {
+			continue
+		}
+		name := nameParts[1]
+		if strings.HasPrefix(name, "~") {
+			if matched, _ := regexp.MatchString(name[1:], item.Name); matched {
+				return true
+			}
+		} else if matched, _ := path.Match(name, item.Name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isLabelRequirement returns true if the exclude value v is a label
+// requirement (e.g. "app=foo", "app notin (foo)" or "!app") as opposed to
+// a kind or a kind/name combination.
+func isLabelRequirement(v string) bool {
+	return strings.Contains(v, "=") || strings.HasPrefix(v, "!") || setRequirementRegex.MatchString(v)
+}
+
 func (f *ResourceFilter) ConvertToTarget() string {
 	if len(f.Name) > 0 {
 		return f.Name
@@ -173,14 +326,26 @@ func (f *ResourceFilter) ConvertToTarget() string {
 	return strings.Join(kinds, ",")
 }
 
+// ConvertToKinds returns the targeted namespaced kinds, comma-separated.
+// Cluster-scoped kinds are not included as they need to be exported by name,
+// see ExportClusterScopedResources. The result is empty if only
+// cluster-scoped kinds are targeted.
 func (f *ResourceFilter) ConvertToKinds() string {
 	if len(f.Name) > 0 {
 		nameParts := strings.Split(f.Name, "/")
+		if IsClusterScoped(nameParts[0]) {
+			return ""
+		}
 		return nameParts[0]
 	}
-	kinds := f.Kinds
-	if len(kinds) == 0 {
-		kinds = availableKinds
+	if len(f.Kinds) == 0 {
+		return strings.Join(availableKinds, ",")
+	}
+	kinds := []string{}
+	for _, kind := range f.Kinds {
+		if !IsClusterScoped(kind) {
+			kinds = append(kinds, kind)
+		}
 	}
 	return strings.Join(kinds, ",")
 }
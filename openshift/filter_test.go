@@ -194,3 +194,103 @@ func makeItem(config []byte) (*ResourceItem, error) {
 	m := f.(map[string]interface{})
 	return NewResourceItem(m, "template")
 }
+
+func TestResourceFilterWithNames(t *testing.T) {
+	actual, err := NewResourceFilter("dc/foo,svc/foo-*", "", "")
+	expected := &ResourceFilter{
+		Kinds: []string{"DeploymentConfig", "Service"},
+		Names: []string{"DeploymentConfig/foo", "Service/foo-*"},
+	}
+	if err != nil || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Filter incorrect, got: %v, want: %v.", actual, expected)
+	}
+
+	invalid := []string{"dc/foo,svc", "xy/foo,dc/bar", "dc/~(foo", "dc/[foo"}
+	for _, kindArg := range invalid {
+		if _, err := NewResourceFilter(kindArg, "", ""); err == nil {
+			t.Errorf("Expected %s to be invalid", kindArg)
+		}
+	}
+
+	tests := map[string]struct {
+		kindArg     string
+		excludeFlag string
+		kind        string
+		name        string
+		expected    bool
+	}{
+		"exact name": {
+			kindArg:  "dc/foo,svc/foo",
+			kind:     "Service",
+			name:     "foo",
+			expected: true,
+		},
+		"other kind": {
+			kindArg:  "dc/foo,svc/foo",
+			kind:     "Route",
+			name:     "foo",
+			expected: false,
+		},
+		"glob": {
+			kindArg:  "dc/foo-*",
+			kind:     "DeploymentConfig",
+			name:     "foo-worker",
+			expected: true,
+		},
+		"glob not matching": {
+			kindArg:  "dc/foo-*",
+			kind:     "DeploymentConfig",
+			name:     "bar-worker",
+			expected: false,
+		},
+		"regex": {
+			kindArg:  "dc/~^foo-(web|worker)$",
+			kind:     "DeploymentConfig",
+			name:     "foo-web",
+			expected: true,
+		},
+		"regex with uppercase class": {
+			kindArg:  "DC/~^foo-\\D+$",
+			kind:     "DeploymentConfig",
+			name:     "foo-web",
+			expected: true,
+		},
+		"regex with uppercase class not matching": {
+			kindArg:  "DC/~^foo-\\D+$",
+			kind:     "DeploymentConfig",
+			name:     "foo-123",
+			expected: false,
+		},
+		"excluded by glob": {
+			kindArg:     "dc/foo-*",
+			excludeFlag: "dc/*-worker",
+			kind:        "DeploymentConfig",
+			name:        "foo-worker",
+			expected:    false,
+		},
+		"excluded by regex with uppercase class": {
+			excludeFlag: "BC/~^foo-\\S+$",
+			kind:        "BuildConfig",
+			name:        "foo-bar",
+			expected:    false,
+		},
+		"excluded by regex": {
+			excludeFlag: "bc/~^foo",
+			kind:        "BuildConfig",
+			name:        "foobar",
+			expected:    false,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter, err := NewResourceFilter(tc.kindArg, "", tc.excludeFlag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			item := &ResourceItem{Kind: tc.kind, Name: tc.name}
+			if actual := filter.SatisfiedBy(item); actual != tc.expected {
+				t.Errorf("Got: %v, want: %v. Filter is: %s", actual, tc.expected, filter)
+			}
+		})
+	}
+}
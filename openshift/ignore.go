@@ -0,0 +1,240 @@
+package openshift
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/opendevstack/tailor/utils"
+)
+
+var (
+	selectorSegmentPattern = regexp.MustCompile(`^([^\[\]]*)\[([^=\]]+)=([^\]]*)\]$`)
+)
+
+// IgnorePath is a parsed ignore-path expression, which consists of an
+// optional scope and a path, separated by a colon. The scope is made up of
+// colon-separated parts, each being either a kind (e.g. "dc"), a name
+// (e.g. "foo"), a name pattern (e.g. "~^foo-") or a label (e.g. "app=foo").
+// The path is a JSON pointer, in which "*" matches any key or index, and
+// "containers[name=foo]" selects the array element whose "name" is "foo".
+type IgnorePath struct {
+	Kind        string
+	Name        string
+	NamePattern *regexp.Regexp
+	Labels      []string
+	Path        string
+}
+
+type pathSegment struct {
+	key           string
+	wildcard      bool
+	selectorKey   string
+	selectorValue string
+}
+
+// ParseIgnorePath parses an ignore-path expression such as
+// "dc:~^foo-:app=foo:/spec/template/spec/containers[name=foo]/image".
+func ParseIgnorePath(expr string) (*IgnorePath, error) {
+	ip := &IgnorePath{}
+	scope := ""
+	if strings.HasPrefix(expr, "/") {
+		ip.Path = expr
+	} else {
+		i := strings.Index(expr, ":/")
+		if i < 0 {
+			return nil, fmt.Errorf("%s is not a valid ignore-path argument", expr)
+		}
+		scope = expr[:i]
+		ip.Path = expr[i+1:]
+	}
+
+	if len(scope) > 0 {
+		for i, part := range strings.Split(scope, ":") {
+			switch {
+			case len(part) == 0:
+				return nil, fmt.Errorf("%s is not a valid ignore-path argument", expr)
+			case strings.HasPrefix(part, "~"):
+				// Checked first as patterns might contain "=".
+				re, err := regexp.Compile(part[1:])
+				if err != nil {
+					return nil, fmt.Errorf("%s contains an invalid name pattern: %s", expr, err)
+				}
+				ip.NamePattern = re
+			case strings.Contains(part, "="):
+				ip.Labels = append(ip.Labels, part)
+			case i == 0 && len(KindMapping[strings.ToLower(part)]) > 0:
+				ip.Kind = KindMapping[strings.ToLower(part)]
+			case len(ip.Name) == 0 && ip.NamePattern == nil:
+				ip.Name = strings.ToLower(part)
+			default:
+				return nil, fmt.Errorf("%s is not a valid ignore-path argument", expr)
+			}
+		}
+	}
+
+	if _, err := parsePathSegments(ip.Path); err != nil {
+		return nil, fmt.Errorf("%s is not a valid ignore-path argument: %s", expr, err)
+	}
+	return ip, nil
+}
+
+// AppliesTo returns true if the scope of the ignore path includes item.
+func (ip *IgnorePath) AppliesTo(item *ResourceItem) bool {
+	if len(ip.Kind) > 0 && ip.Kind != item.Kind {
+		return false
+	}
+	if len(ip.Name) > 0 && ip.Name != item.Name {
+		return false
+	}
+	if ip.NamePattern != nil && !ip.NamePattern.MatchString(item.Name) {
+		return false
+	}
+	for _, label := range ip.Labels {
+		if !item.HasLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePathSegments(path string) ([]pathSegment, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path %s must start with /", path)
+	}
+	segments := []pathSegment{}
+	for _, part := range strings.Split(path[1:], "/") {
+		if part == "*" {
+			segments = append(segments, pathSegment{wildcard: true})
+			continue
+		}
+		if strings.ContainsAny(part, "[]") {
+			matches := selectorSegmentPattern.FindStringSubmatch(part)
+			if matches == nil {
+				return nil, fmt.Errorf("%s is not a valid array selector", part)
+			}
+			segments = append(segments, pathSegment{
+				key:           unescapePointerSegment(matches[1]),
+				selectorKey:   matches[2],
+				selectorValue: matches[3],
+			})
+			continue
+		}
+		segments = append(segments, pathSegment{key: unescapePointerSegment(part)})
+	}
+	return segments, nil
+}
+
+// resolvePathExpression expands the path expression against the platform
+// and the template config. It returns pairs of concrete JSON pointers, the
+// first pointing into the platform config and the second pointing to the
+// corresponding location in the template config. Array elements chosen via
+// selectors might be located at different indices in both configs.
+func resolvePathExpression(path string, platformConfig, templateConfig map[string]interface{}) [][2]string {
+	segments, err := parsePathSegments(path)
+	if err != nil {
+		return [][2]string{}
+	}
+	pairs := [][2]string{}
+	resolveSegments(segments, platformConfig, templateConfig, "", "", &pairs)
+	return pairs
+}
+
+func resolveSegments(segments []pathSegment, platformVal, templateVal interface{}, platformPointer, templatePointer string, pairs *[][2]string) {
+	if len(segments) == 0 {
+		*pairs = append(*pairs, [2]string{platformPointer, templatePointer})
+		return
+	}
+	segment := segments[0]
+	rest := segments[1:]
+
+	if segment.wildcard {
+		switch pv := platformVal.(type) {
+		case map[string]interface{}:
+			tv, _ := templateVal.(map[string]interface{})
+			for k, v := range pv {
+				resolveSegments(rest, v, tv[k], platformPointer+"/"+utils.JSONPointerPath(k), templatePointer+"/"+utils.JSONPointerPath(k), pairs)
+			}
+		case []interface{}:
+			tv, _ := templateVal.([]interface{})
+			for i, v := range pv {
+				var t interface{}
+				if i < len(tv) {
+					t = tv[i]
+				}
+				resolveSegments(rest, v, t, platformPointer+"/"+strconv.Itoa(i), templatePointer+"/"+strconv.Itoa(i), pairs)
+			}
+		}
+		return
+	}
+
+	var platformChild, templateChild interface{}
+	switch pv := platformVal.(type) {
+	case map[string]interface{}:
+		v, ok := pv[segment.key]
+		if !ok {
+			return
+		}
+		platformChild = v
+		if tv, ok := templateVal.(map[string]interface{}); ok {
+			templateChild = tv[segment.key]
+		}
+	case []interface{}:
+		i, err := strconv.Atoi(segment.key)
+		if err != nil || i >= len(pv) {
+			return
+		}
+		platformChild = pv[i]
+		if tv, ok := templateVal.([]interface{}); ok && i < len(tv) {
+			templateChild = tv[i]
+		}
+	default:
+		return
+	}
+	platformPointer = platformPointer + "/" + utils.JSONPointerPath(segment.key)
+	templatePointer = templatePointer + "/" + utils.JSONPointerPath(segment.key)
+
+	if len(segment.selectorKey) == 0 {
+		resolveSegments(rest, platformChild, templateChild, platformPointer, templatePointer, pairs)
+		return
+	}
+
+	platformIndex := selectArrayElement(platformChild, segment.selectorKey, segment.selectorValue)
+	templateIndex := selectArrayElement(templateChild, segment.selectorKey, segment.selectorValue)
+	if platformIndex < 0 || templateIndex < 0 {
+		return
+	}
+	resolveSegments(
+		rest,
+		platformChild.([]interface{})[platformIndex],
+		templateChild.([]interface{})[templateIndex],
+		platformPointer+"/"+strconv.Itoa(platformIndex),
+		templatePointer+"/"+strconv.Itoa(templateIndex),
+		pairs,
+	)
+}
+
+// selectArrayElement returns the index of the first element in a whose
+// field key has given value, or -1 if there is none.
+func selectArrayElement(a interface{}, key string, value string) int {
+	elements, ok := a.([]interface{})
+	if !ok {
+		return -1
+	}
+	for i, e := range elements {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v, ok := m[key]; ok && fmt.Sprintf("%v", v) == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapePointerSegment(s string) string {
+	s = strings.Replace(s, "~1", "/", -1)
+	return strings.Replace(s, "~0", "~", -1)
+}
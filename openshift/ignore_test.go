@@ -0,0 +1,240 @@
+package openshift
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseIgnorePath(t *testing.T) {
+	tests := map[string]struct {
+		expr         string
+		kind         string
+		name         string
+		namePattern  string
+		labels       []string
+		path         string
+		expectsError bool
+	}{
+		"global": {
+			expr: "/spec/name",
+			path: "/spec/name",
+		},
+		"per kind": {
+			expr: "bc:/spec/name",
+			kind: "BuildConfig",
+			path: "/spec/name",
+		},
+		"per resource": {
+			expr: "bc:Foo:/spec/name",
+			kind: "BuildConfig",
+			name: "foo",
+			path: "/spec/name",
+		},
+		"per name pattern": {
+			expr:        "dc:~^foo-:/spec/replicas",
+			kind:        "DeploymentConfig",
+			namePattern: "^foo-",
+			path:        "/spec/replicas",
+		},
+		"per name pattern containing =": {
+			expr:        "dc:~^a=b:/spec/replicas",
+			kind:        "DeploymentConfig",
+			namePattern: "^a=b",
+			path:        "/spec/replicas",
+		},
+		"per label": {
+			expr:   "dc:autoscaled=true:/spec/replicas",
+			kind:   "DeploymentConfig",
+			labels: []string{"autoscaled=true"},
+			path:   "/spec/replicas",
+		},
+		"with wildcard and selector": {
+			expr: "dc:/spec/template/spec/containers[name=app]/env/*/value",
+			kind: "DeploymentConfig",
+			path: "/spec/template/spec/containers[name=app]/env/*/value",
+		},
+		"missing path": {
+			expr:         "bc:foo",
+			expectsError: true,
+		},
+		"too many names": {
+			expr:         "bc:foo:bar:/spec/name",
+			expectsError: true,
+		},
+		"invalid name pattern": {
+			expr:         "bc:~(foo:/spec/name",
+			expectsError: true,
+		},
+		"invalid selector": {
+			expr:         "/spec/containers[name]/image",
+			expectsError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ip, err := ParseIgnorePath(tc.expr)
+			if tc.expectsError {
+				if err == nil {
+					t.Errorf("Expected %s to be invalid", tc.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ip.Kind != tc.kind || ip.Name != tc.name || ip.Path != tc.path || !reflect.DeepEqual(ip.Labels, tc.labels) {
+				t.Errorf("Got %+v", ip)
+			}
+			if len(tc.namePattern) > 0 && (ip.NamePattern == nil || ip.NamePattern.String() != tc.namePattern) {
+				t.Errorf("Got name pattern %v, want %s", ip.NamePattern, tc.namePattern)
+			}
+		})
+	}
+}
+
+func TestIgnorePathAppliesTo(t *testing.T) {
+	item := getItem(t, getBuildConfig(), "template")
+	tests := map[string]bool{
+		"/spec/name":             true,
+		"bc:/spec/name":          true,
+		"dc:/spec/name":          false,
+		"bc:foo:/spec/name":      true,
+		"bc:bar:/spec/name":      false,
+		"bc:~^f.o$:/spec/name":   true,
+		"bc:~^bar:/spec/name":    false,
+		"bc:app=foo:/spec/name":  true,
+		"bc:app=bar:/spec/name":  false,
+		"app=foo:/spec/name":     true,
+		"foo:app=foo:/spec/name": true,
+	}
+	for expr, expected := range tests {
+		ip, err := ParseIgnorePath(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := ip.AppliesTo(item); actual != expected {
+			t.Errorf("%s: got %v, want %v", expr, actual, expected)
+		}
+	}
+}
+
+func TestResolvePathExpression(t *testing.T) {
+	platformConfig := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "sidecar", "image": "sidecar@sha256:1"},
+				map[string]interface{}{"name": "app", "image": "app@sha256:2"},
+			},
+		},
+	}
+	templateConfig := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:latest"},
+				map[string]interface{}{"name": "sidecar", "image": "sidecar:latest"},
+			},
+		},
+	}
+
+	tests := map[string][][2]string{
+		"/spec/containers/*/image": [][2]string{
+			{"/spec/containers/0/image", "/spec/containers/0/image"},
+			{"/spec/containers/1/image", "/spec/containers/1/image"},
+		},
+		"/spec/containers[name=app]/image": [][2]string{
+			{"/spec/containers/1/image", "/spec/containers/0/image"},
+		},
+		"/spec/containers[name=other]/image": [][2]string{},
+		"/spec/containers/0/image": [][2]string{
+			{"/spec/containers/0/image", "/spec/containers/0/image"},
+		},
+		"/spec/foo": [][2]string{},
+	}
+	for expr, expected := range tests {
+		actual := resolvePathExpression(expr, platformConfig, templateConfig)
+		sort.Slice(actual, func(i, j int) bool { return actual[i][0] < actual[j][0] })
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: got %v, want %v", expr, actual, expected)
+		}
+	}
+}
+
+func TestConfigIgnoredPathExpressions(t *testing.T) {
+	templateInput := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    labels:
+      autoscaled: "true"
+    name: foo-a
+  spec:
+    replicas: 1
+    template:
+      spec:
+        containers:
+        - imagePullPolicy: Always
+          name: app
+          resources:
+            limits:
+              memory: 1Gi
+        - imagePullPolicy: Always
+          name: sidecar
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    labels:
+      autoscaled: "true"
+    name: foo-b
+  spec:
+    replicas: 1`)
+
+	platformInput := []byte(
+		`kind: Template
+apiVersion: v1
+objects:
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    labels:
+      autoscaled: "true"
+    name: foo-a
+  spec:
+    replicas: 3
+    template:
+      spec:
+        containers:
+        - imagePullPolicy: IfNotPresent
+          name: app
+          resources:
+            limits:
+              memory: 2Gi
+        - imagePullPolicy: IfNotPresent
+          name: sidecar
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    labels:
+      autoscaled: "true"
+    name: foo-b
+  spec:
+    replicas: 5`)
+
+	filter := &ResourceFilter{
+		Kinds: []string{"DeploymentConfig"},
+	}
+	changeset := getChangeset(t, filter, platformInput, templateInput, false, []string{
+		"dc:autoscaled=true:/spec/replicas",
+		"dc:~^foo-:/spec/template/spec/containers[name=app]/resources",
+		"/spec/template/spec/containers/*/imagePullPolicy",
+	})
+	if len(changeset.Update) != 0 {
+		for i, u := range changeset.Update {
+			t.Errorf("Patchset Update#%d: %s", i, u.JsonPatches(true))
+		}
+	}
+}
@@ -1,8 +1,8 @@
 package openshift
 
 import (
+	"encoding/json"
 	"fmt"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,38 +16,11 @@ import (
 var (
 	tailorOriginalValuesAnnotationPrefix = "original-values.tailor.io"
 	tailorManagedAnnotation              = "managed-annotations.tailor.opendevstack.org"
-	platformManagedSimpleFields          = []string{
-		"/metadata/generation",
-		"/metadata/creationTimestamp",
-		"/spec/tags",
-		"/status",
-		"/spec/volumeName",
-		"/spec/template/metadata/creationTimestamp",
-	}
-	platformManagedRegexFields = []string{
-		"^/spec/triggers/[0-9]*/imageChangeParams/lastTriggeredImage",
-	}
-	emptyMapFields = []string{
+	tailorIgnorePathsAnnotation          = "tailor.opendevstack.org/ignore-paths"
+	emptyMapFields                       = []string{
 		"/metadata/annotations",
 		"/spec/template/metadata/annotations",
 	}
-	immutableFields = map[string][]string{
-		"PersistentVolumeClaim": []string{
-			"/spec/accessModes",
-			"/spec/storageClassName",
-			"/spec/resources/requests/storage",
-		},
-		"Route": []string{
-			"/spec/host",
-		},
-		"Secret": []string{
-			"/type",
-		},
-	}
-	platformModifiedFields = []string{
-		"/spec/template/spec/containers/[0-9]+/image$",
-	}
-
 	KindMapping = map[string]string{
 		"svc":                   "Service",
 		"service":               "Service",
@@ -66,6 +39,10 @@ var (
 		"secret":                "Secret",
 		"rolebinding":           "RoleBinding",
 		"serviceaccount":        "ServiceAccount",
+		"project":               "Project",
+		"clusterresourcequota":  "ClusterResourceQuota",
+		"clusterrole":           "ClusterRole",
+		"clusterrolebinding":    "ClusterRoleBinding",
 	}
 )
 
@@ -78,6 +55,13 @@ type ResourceItem struct {
 	Paths                    []string
 	Config                   map[string]interface{}
 	TailorManagedAnnotations []string
+	IgnorePaths              []string
+	// TemplateFile, TemplateIndex and TemplateLine locate the object in
+	// the template the item was processed from (template based items only).
+	// TemplateLine is 0 if the line is unknown.
+	TemplateFile  string
+	TemplateIndex int
+	TemplateLine  int
 }
 
 func NewResourceItem(m map[string]interface{}, source string) (*ResourceItem, error) {
@@ -90,14 +74,28 @@ func (i *ResourceItem) FullName() string {
 	return i.Kind + "/" + i.Name
 }
 
+// Origin describes where the item is defined, e.g. "foo.yml:12, object #2".
+// It is empty for items which do not originate from a template.
+func (i *ResourceItem) Origin() string {
+	if len(i.TemplateFile) == 0 {
+		return ""
+	}
+	if i.TemplateLine > 0 {
+		return fmt.Sprintf("%s:%d, object #%d", i.TemplateFile, i.TemplateLine, i.TemplateIndex+1)
+	}
+	return fmt.Sprintf("%s, object #%d", i.TemplateFile, i.TemplateIndex+1)
+}
+
+// HasLabel returns true if the labels of the item meet the requirement,
+// which can be given in any form supported by label selectors, e.g.
+// "app=foo", "app!=foo", "app in (foo, bar)", "app" or "!app". Invalid
+// requirements are never met.
 func (i *ResourceItem) HasLabel(label string) bool {
-	labelParts := strings.Split(label, "=")
-	if _, ok := i.Labels[labelParts[0]]; !ok {
-		return false
-	} else if i.Labels[labelParts[0]].(string) != labelParts[1] {
+	r, err := parseLabelRequirement(label)
+	if err != nil {
 		return false
 	}
-	return true
+	return r.matches(i.Labels)
 }
 
 func (templateItem *ResourceItem) ChangesFrom(platformItem *ResourceItem, externallyModifiedPaths []string) ([]*Change, error) {
@@ -144,7 +142,7 @@ func (templateItem *ResourceItem) ChangesFrom(platformItem *ResourceItem, extern
 				// map content changed, continue
 				comparison[path] = &jsonPatch{Op: "noop"}
 			default:
-				if templateItemVal == platformItemVal {
+				if normalizedValue(path, templateItemVal) == normalizedValue(path, platformItemVal) {
 					comparison[path] = &jsonPatch{Op: "noop"}
 				} else {
 					if templateItem.isImmutableField(path) {
@@ -247,6 +245,17 @@ func (i *ResourceItem) parseConfig(m map[string]interface{}) error {
 		}
 	}
 
+	// Extract paths to ignore declared by the resource itself
+	i.IgnorePaths = []string{}
+	if val, ok := i.Annotations[tailorIgnorePathsAnnotation].(string); ok {
+		for _, p := range strings.Split(val, ",") {
+			p = strings.TrimSpace(p)
+			if len(p) > 0 {
+				i.IgnorePaths = append(i.IgnorePaths, p)
+			}
+		}
+	}
+
 	// Figure out which annotations are managed by Tailor
 	i.TailorManagedAnnotations = []string{}
 	if i.Source == "platform" {
@@ -270,9 +279,11 @@ func (i *ResourceItem) parseConfig(m map[string]interface{}) error {
 	}
 
 	// Remove platform-managed simple fields
-	for _, p := range platformManagedSimpleFields {
-		deletePointer, _ := gojsonpointer.NewJsonPointer(p)
-		_, _ = deletePointer.Delete(m)
+	for _, r := range rulesFor(i.Kind, IgnoreAction) {
+		if len(r.Path) > 0 {
+			deletePointer, _ := gojsonpointer.NewJsonPointer(r.Path)
+			_, _ = deletePointer.Delete(m)
+		}
 	}
 
 	i.Config = m
@@ -286,21 +297,22 @@ func (i *ResourceItem) parseConfig(m map[string]interface{}) error {
 	for pathIndex, path := range i.Paths {
 
 		// Remove platform-managed regex fields
-		for _, platformManagedField := range platformManagedRegexFields {
-			matched, _ := regexp.MatchString(platformManagedField, path)
-			if matched {
+		for _, r := range rulesFor(i.Kind, IgnoreAction) {
+			if len(r.Pattern) > 0 && r.matches(path) {
 				deletePointer, _ := gojsonpointer.NewJsonPointer(path)
 				_, _ = deletePointer.Delete(i.Config)
 				deletedPathIndices = append(deletedPathIndices, pathIndex)
+				// The path must only be deleted once, even if several
+				// rules match.
+				break
 			}
 		}
 
 		// Deal with platform-modified fields
 		// If there is an annotation, copy its value into the spec, otherwise
 		// copy the spec value into the annotation.
-		for _, platformModifiedField := range platformModifiedFields {
-			matched, _ := regexp.MatchString(platformModifiedField, path)
-			if matched {
+		for _, r := range rulesFor(i.Kind, PreserveAction) {
+			if r.matches(path) {
 				annotationKey := strings.Replace(strings.TrimLeft(path, "/"), "/", ".", -1)
 				annotationPath := "/metadata/annotations/" + tailorOriginalValuesAnnotationPrefix + "~1" + annotationKey
 				annotationPointer, _ := gojsonpointer.NewJsonPointer(annotationPath)
@@ -308,8 +320,9 @@ func (i *ResourceItem) parseConfig(m map[string]interface{}) error {
 				specValue, _, _ := specPointer.Get(i.Config)
 				annotationValue, _, err := annotationPointer.Get(i.Config)
 				if err == nil {
-					cli.DebugMsg("Platform: Setting", path, "to", annotationValue.(string))
-					_, err := specPointer.Set(i.Config, annotationValue)
+					originalValue := restoredOriginalValue(annotationValue, specValue)
+					cli.DebugMsg("Platform: Setting", path, "to", fmt.Sprintf("%v", originalValue))
+					_, err := specPointer.Set(i.Config, originalValue)
 					if err != nil {
 						return err
 					}
@@ -321,8 +334,9 @@ func (i *ResourceItem) parseConfig(m map[string]interface{}) error {
 						_, _ = anP.Set(i.Config, map[string]interface{}{})
 						newPaths = append(newPaths, "/metadata/annotations")
 					}
-					cli.DebugMsg("Template: Setting", annotationPath, "to", specValue.(string))
-					_, err = annotationPointer.Set(i.Config, specValue)
+					annotationValue := originalValueAnnotation(specValue)
+					cli.DebugMsg("Template: Setting", annotationPath, "to", annotationValue)
+					_, err = annotationPointer.Set(i.Config, annotationValue)
 					if err != nil {
 						return err
 					}
@@ -369,14 +383,25 @@ func (i *ResourceItem) RemoveUnmanagedAnnotations() {
 }
 
 func (i *ResourceItem) isImmutableField(field string) bool {
-	for _, key := range immutableFields[i.Kind] {
-		if key == field {
+	for _, r := range rulesFor(i.Kind, RecreateAction) {
+		if r.matches(field) {
 			return true
 		}
 	}
 	return false
 }
 
+// removeSubpaths removes all paths below given path from the list of paths.
+func (i *ResourceItem) removeSubpaths(path string) {
+	paths := []string{}
+	for _, p := range i.Paths {
+		if !strings.HasPrefix(p, path+"/") {
+			paths = append(paths, p)
+		}
+	}
+	i.Paths = paths
+}
+
 func (i *ResourceItem) walkMap(m map[string]interface{}, pointer string) {
 	for k, v := range m {
 		i.handleKeyValue(k, v, pointer)
@@ -432,13 +457,22 @@ func recreateChanges(templateItem, platformItem *ResourceItem) []*Change {
 // prepareForComparisonWithPlatformItem massages template item in such a way
 // that it can be compared with the given platform item:
 // - copy value from platformItem to templateItem for externally modified paths
+// Paths may contain wildcards and array selectors, see IgnorePath.
 func (templateItem *ResourceItem) prepareForComparisonWithPlatformItem(platformItem *ResourceItem, externallyModifiedPaths []string) error {
-	for _, path := range externallyModifiedPaths {
-		pathPointer, _ := gojsonpointer.NewJsonPointer(path)
-		platformItemVal, _, err := pathPointer.Get(platformItem.Config)
-		if err != nil {
-			cli.DebugMsg("No such path", path, "in platform item", platformItem.FullName())
-		} else {
+	for _, expr := range externallyModifiedPaths {
+		pairs := resolvePathExpression(expr, platformItem.Config, templateItem.Config)
+		if len(pairs) == 0 {
+			cli.DebugMsg("No such path", expr, "in platform item", platformItem.FullName())
+		}
+		for _, pair := range pairs {
+			platformPath, path := pair[0], pair[1]
+			platformPointer, _ := gojsonpointer.NewJsonPointer(platformPath)
+			platformItemVal, _, err := platformPointer.Get(platformItem.Config)
+			if err != nil {
+				cli.DebugMsg("No such path", platformPath, "in platform item", platformItem.FullName())
+				continue
+			}
+			pathPointer, _ := gojsonpointer.NewJsonPointer(path)
 			_, err = pathPointer.Set(templateItem.Config, platformItemVal)
 			if err != nil {
 				cli.DebugMsg(fmt.Sprintf(
@@ -448,8 +482,9 @@ func (templateItem *ResourceItem) prepareForComparisonWithPlatformItem(platformI
 					templateItem.FullName(),
 				))
 			} else {
-				// Add ignored path and its subpaths to the paths slice
+				// Replace ignored path and its subpaths in the paths slice
 				// of the template item.
+				templateItem.removeSubpaths(path)
 				templateItem.Paths = append(templateItem.Paths, path)
 				switch vv := platformItemVal.(type) {
 				case []interface{}:
@@ -496,3 +531,34 @@ func (platformItem *ResourceItem) prepareForComparisonWithTemplateItem(templateI
 	}
 	return nil
 }
+
+// originalValueAnnotation returns the value to store in an original-values
+// annotation. As annotations can only hold strings, other values are
+// serialised as JSON.
+func originalValueAnnotation(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
+
+// restoredOriginalValue converts the value of an original-values annotation
+// back into the type of the current value of the field.
+func restoredOriginalValue(annotationValue interface{}, currentValue interface{}) interface{} {
+	s, ok := annotationValue.(string)
+	if !ok {
+		return annotationValue
+	}
+	if _, isString := currentValue.(string); isString || currentValue == nil {
+		return s
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	return v
+}
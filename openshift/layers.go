@@ -0,0 +1,280 @@
+package openshift
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/utils"
+)
+
+// GlobalParamFilename is the name of the param file in a param dir which
+// holds defaults shared by all templates.
+const GlobalParamFilename = "_global.env"
+
+// ParamDefinition is a value given to a parameter by one source, e.g. a
+// param file or the command line.
+type ParamDefinition struct {
+	Name string
+	// Key is the key as written in the source, e.g. "TOKEN.B64".
+	Key string
+	// Value is the value passed to the template, which is base64-encoded for
+	// encrypted params.
+	Value string
+	// Cleartext is the value as written in the source after decryption.
+	Cleartext string
+	Source    string
+	Encrypted bool
+}
+
+// ParamLayer groups the param definitions of one level of precedence.
+type ParamLayer struct {
+	Name        string
+	Definitions []*ParamDefinition
+	// If DeclaredOnly is true, only definitions of parameters declared by
+	// the template are used. This allows to share defaults among templates.
+	DeclaredOnly bool
+}
+
+// ParamLayers is a list of layers, ordered by increasing precedence.
+type ParamLayers []*ParamLayer
+
+// ReadParamLayers reads the param layers of the template with given name.
+// If --param-file is given, each param file is a layer of its own (in the
+// given order), followed by the command line. Otherwise, the layers are (by increasing precedence):
+// global defaults (<paramDir>/_global.env), the template param file
+// (<paramDir>/<template>.env), the namespace param file
+// (<paramDir>/<namespace>/<template>.env) and the command line. Next to each
+// param file, an encrypted param file (.env.enc) may exist which belongs to
+// the same layer. Encrypted values are decrypted and encoded only if decrypt
+// is true.
+func ReadParamLayers(name string, paramDir string, compareOptions *cli.CompareOptions, decrypt bool) (ParamLayers, error) {
+	layers := ParamLayers{}
+	if len(compareOptions.ParamFiles) > 0 {
+		for _, f := range compareOptions.ParamFiles {
+			layer := &ParamLayer{Name: "param file"}
+			err := layer.readFiles(f, compareOptions, decrypt)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, layer)
+		}
+	} else {
+		cli.DebugMsg(fmt.Sprintf("Looking for param files in '%s'", paramDir))
+		paramFile := paramFilename(name)
+		global := &ParamLayer{Name: "global", DeclaredOnly: true}
+		template := &ParamLayer{Name: "template"}
+		namespace := &ParamLayer{Name: "namespace"}
+		files := map[*ParamLayer]string{
+			global:    paramDir + string(os.PathSeparator) + GlobalParamFilename,
+			template:  paramDir + string(os.PathSeparator) + paramFile,
+			namespace: NamespaceParamFilename(name, paramDir, compareOptions.Namespace),
+		}
+		for _, layer := range []*ParamLayer{global, template, namespace} {
+			// Without a namespace, the namespace param file would be the
+			// template param file.
+			if layer != namespace || len(compareOptions.Namespace) > 0 {
+				err := layer.readFiles(files[layer], compareOptions, decrypt)
+				if err != nil {
+					return nil, err
+				}
+			}
+			layers = append(layers, layer)
+		}
+	}
+
+	commandLine := &ParamLayer{Name: "command line"}
+	for _, p := range compareOptions.Params {
+		pair := strings.SplitN(p, "=", 2)
+		val := ""
+		if len(pair) > 1 {
+			val = pair[1]
+		}
+		commandLine.Definitions = append(commandLine.Definitions, &ParamDefinition{
+			Name:      pair[0],
+			Key:       pair[0],
+			Value:     val,
+			Cleartext: val,
+			Source:    "--param",
+		})
+	}
+	layers = append(layers, commandLine)
+
+	return layers, nil
+}
+
+// NamespaceParamFilename returns the path of the param file of the namespace
+// layer for the template with given name, which might not exist.
+func NamespaceParamFilename(name string, paramDir string, namespace string) string {
+	return paramDir + string(os.PathSeparator) + namespace + string(os.PathSeparator) + paramFilename(name)
+}
+
+// readFiles adds the definitions of given param file and of the encrypted
+// param file next to it. Files which do not exist are skipped.
+func (l *ParamLayer) readFiles(filename string, compareOptions *cli.CompareOptions, decrypt bool) error {
+	for _, f := range []string{filename, filename + ".enc"} {
+		content, err := utils.ReadFile(f)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("Could not read file: %s", err)
+		}
+		encrypted := strings.HasSuffix(f, ".enc")
+		cli.DebugMsg("Reading content of param file", f)
+		if encrypted && decrypt {
+			content, err = DecryptedParams(content, compareOptions.PrivateKey, compareOptions.Passphrase)
+			if err != nil {
+				return fmt.Errorf("Could not decrypt %s: %s", f, err)
+			}
+		}
+		err = extractKeyValuePairs(content, func(key, val string) error {
+			d := &ParamDefinition{
+				Name:      key,
+				Key:       key,
+				Value:     val,
+				Cleartext: val,
+				Source:    f,
+				Encrypted: encrypted,
+			}
+			if encrypted {
+				d.Name = strings.TrimSuffix(key, ".B64")
+				if decrypt {
+					_, d.Value, _ = (&paramConverter{}).encode(key, val)
+				}
+			}
+			l.Definitions = append(l.Definitions, d)
+			return nil
+		}, func(line string) {})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sources returns the distinct sources of the definitions in the layer.
+func (l *ParamLayer) Sources() []string {
+	sources := []string{}
+	for _, d := range l.Definitions {
+		if !utils.Includes(sources, d.Source) {
+			sources = append(sources, d.Source)
+		}
+	}
+	return sources
+}
+
+// Keys returns the parameter names defined in the layer in order, including
+// duplicates.
+func (l *ParamLayer) Keys() []string {
+	keys := []string{}
+	for _, d := range l.Definitions {
+		keys = append(keys, d.Name)
+	}
+	return keys
+}
+
+// applies returns true if the definition of given parameter is used.
+func (l *ParamLayer) applies(name string, declared []string) bool {
+	return !l.DeclaredOnly || utils.Includes(declared, name)
+}
+
+// Conflicts returns a description of each parameter which is defined with
+// different values within a single layer. Repeating the same value is not
+// considered a conflict.
+func (layers ParamLayers) Conflicts() []string {
+	conflicts := []string{}
+	for _, l := range layers {
+		values := map[string][]string{}
+		sources := map[string][]string{}
+		for _, d := range l.Definitions {
+			if !utils.Includes(values[d.Name], d.Value) {
+				values[d.Name] = append(values[d.Name], d.Value)
+			}
+			if !utils.Includes(sources[d.Name], d.Source) {
+				sources[d.Name] = append(sources[d.Name], d.Source)
+			}
+		}
+		names := []string{}
+		for name, v := range values {
+			if len(v) > 1 {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			conflicts = append(conflicts, fmt.Sprintf(
+				"%s is defined with different values in the %s layer (%s)",
+				name, l.Name, strings.Join(sources[name], ", "),
+			))
+		}
+	}
+	return conflicts
+}
+
+// Resolve returns the final definition of each parameter, which is the last
+// definition of the layer with the highest precedence. Parameters of layers
+// with DeclaredOnly are skipped unless they are included in declared.
+func (layers ParamLayers) Resolve(declared []string) map[string]*ParamDefinition {
+	resolved := map[string]*ParamDefinition{}
+	for _, l := range layers {
+		for _, d := range l.Definitions {
+			if l.applies(d.Name, declared) {
+				resolved[d.Name] = d
+			}
+		}
+	}
+	return resolved
+}
+
+// Content returns the resolved parameters in param file format, sorted by
+// name.
+func (layers ParamLayers) Content(declared []string) string {
+	resolved := layers.Resolve(declared)
+	names := []string{}
+	for name := range resolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	content := ""
+	for _, name := range names {
+		content = content + name + "=" + resolved[name].Value + "\n"
+	}
+	return content
+}
+
+// Explain describes how the value of given parameter is determined, listing
+// each definition and marking the one which is used. It returns an empty
+// string if no layer defines the parameter.
+func (layers ParamLayers) Explain(name string, declared []string) string {
+	final := layers.Resolve(declared)[name]
+	lines := []string{}
+	for _, l := range layers {
+		for _, d := range l.Definitions {
+			if d.Name != name {
+				continue
+			}
+			value := strconv.Quote(d.Value)
+			if d.Encrypted {
+				value = "(encrypted)"
+			}
+			line := fmt.Sprintf("  %s (%s): %s", l.Name, d.Source, value)
+			switch {
+			case d == final:
+				line = line + " <- final value"
+			case !l.applies(name, declared):
+				line = line + " (ignored as not declared by template)"
+			default:
+				line = line + " (overridden)"
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,82 @@
+package openshift
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opendevstack/tailor/cli"
+)
+
+func TestReadParamLayers(t *testing.T) {
+	compareOptions := &cli.CompareOptions{
+		GlobalOptions: &cli.GlobalOptions{Namespace: "foo-dev"},
+		Params:        []string{"REPLICAS=3"},
+	}
+	layers, err := ReadParamLayers("foo.yml", "../testdata/layered-params", compareOptions, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, l := range layers {
+		names = append(names, l.Name)
+	}
+	expectedNames := "global, template, namespace, command line"
+	if strings.Join(names, ", ") != expectedNames {
+		t.Fatalf("Got layers %v, want %s", names, expectedNames)
+	}
+
+	declared := []string{"REGISTRY", "REPLICAS", "DB_HOST", "MEMORY"}
+	content := layers.Content(declared)
+	expectedContent := "DB_HOST=db.dev\nMEMORY=2Gi\nREGISTRY=registry.example.com\nREPLICAS=3\n"
+	if content != expectedContent {
+		t.Errorf("Got content:\n%s\nwant:\n%s", content, expectedContent)
+	}
+
+	conflicts := layers.Conflicts()
+	if len(conflicts) != 1 || !strings.HasPrefix(conflicts[0], "MEMORY is defined with different values in the namespace layer") {
+		t.Errorf("Got conflicts %v, want only MEMORY in namespace layer", conflicts)
+	}
+
+	explanation := layers.Explain("REPLICAS", declared)
+	expectedExplanation := `  global (../testdata/layered-params/_global.env): "1" (overridden)
+  template (../testdata/layered-params/foo.env): "2" (overridden)
+  command line (--param): "3" <- final value
+`
+	if explanation != expectedExplanation {
+		t.Errorf("Got explanation:\n%s\nwant:\n%s", explanation, expectedExplanation)
+	}
+
+	explanation = layers.Explain("UNDECLARED", declared)
+	if !strings.Contains(explanation, "(ignored as not declared by template)") {
+		t.Errorf("Global param not declared by template should be ignored, got:\n%s", explanation)
+	}
+
+	if len(layers.Explain("UNKNOWN", declared)) > 0 {
+		t.Errorf("Undefined param should have no explanation")
+	}
+}
+
+func TestReadParamLayersWithParamFile(t *testing.T) {
+	compareOptions := &cli.CompareOptions{
+		GlobalOptions: &cli.GlobalOptions{Namespace: "foo-dev"},
+		ParamFiles: []string{
+			"../testdata/layered-params/_global.env",
+			"../testdata/layered-params/foo.env",
+		},
+	}
+	layers, err := ReadParamLayers("bar.yml", "../testdata/layered-params", compareOptions, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 3 {
+		t.Fatalf("Expected one layer per param file plus the command line, got %d", len(layers))
+	}
+	content := layers.Content([]string{})
+	expectedContent := "DB_HOST=db\nREGISTRY=registry.example.com\nREPLICAS=2\nUNDECLARED=x\n"
+	if content != expectedContent {
+		t.Errorf("Got content:\n%s\nwant:\n%s", content, expectedContent)
+	}
+	if len(layers.Conflicts()) > 0 {
+		t.Errorf("Later param files should override earlier ones without conflict, got %v", layers.Conflicts())
+	}
+}
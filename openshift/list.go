@@ -2,6 +2,10 @@ package openshift
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
 
 	"github.com/ghodss/yaml"
 	"github.com/opendevstack/tailor/cli"
@@ -26,11 +30,75 @@ func NewPlatformBasedResourceList(filter *ResourceFilter, inputs ...[]byte) (*Re
 	return list, err
 }
 
+// AppendProcessedTemplate adds the items of given processed template to the
+// list, recording templateFile as the file they are defined in. If the
+// template file can be read, the line of each object is recorded as well.
+func (l *ResourceList) AppendProcessedTemplate(templateFile string, input []byte) error {
+	before := len(l.Items)
+	err := l.appendItems("template", "/items", input)
+	objectLines := []int{}
+	if content, readErr := ioutil.ReadFile(templateFile); readErr == nil {
+		objectLines = templateObjectLines(content)
+	}
+	for _, item := range l.Items[before:] {
+		item.TemplateFile = templateFile
+		if item.TemplateIndex < len(objectLines) {
+			item.TemplateLine = objectLines[item.TemplateIndex]
+		}
+	}
+	return err
+}
+
+// CheckDuplicates returns an error listing all items which are defined more
+// than once, together with the templates defining them.
+func (l *ResourceList) CheckDuplicates() error {
+	templateFiles := map[string][]string{}
+	names := []string{}
+	for _, item := range l.Items {
+		name := item.FullName()
+		if _, ok := templateFiles[name]; !ok {
+			names = append(names, name)
+		}
+		origin := item.Origin()
+		if len(origin) == 0 {
+			origin = "unknown template"
+		}
+		templateFiles[name] = append(templateFiles[name], origin)
+	}
+	sort.Strings(names)
+
+	duplicates := []string{}
+	for _, name := range names {
+		if len(templateFiles[name]) > 1 {
+			duplicates = append(duplicates, fmt.Sprintf(
+				"- %s (in %s)",
+				name,
+				strings.Join(templateFiles[name], "; "),
+			))
+		}
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf(
+			"Resources must be defined only once, but the following are defined multiple times:\n%s",
+			strings.Join(duplicates, "\n"),
+		)
+	}
+	return nil
+}
+
 // Length returns the number of items in the resource list
 func (l *ResourceList) Length() int {
 	return len(l.Items)
 }
 
+// RemoveServerDefaults removes fields set to their server default from all
+// items in the list.
+func (l *ResourceList) RemoveServerDefaults() {
+	for _, item := range l.Items {
+		item.RemoveServerDefaults()
+	}
+}
+
 func (l *ResourceList) getItem(kind string, name string) (*ResourceItem, error) {
 	for _, item := range l.Items {
 		if item.Kind == kind && item.Name == name {
@@ -60,11 +128,14 @@ func (l *ResourceList) appendItems(source, itemsField string, inputs ...[]byte)
 		if err != nil {
 			return err
 		}
-		for _, v := range items.([]interface{}) {
+		for index, v := range items.([]interface{}) {
 			item, err := NewResourceItem(v.(map[string]interface{}), source)
 			if err != nil {
 				return err
 			}
+			if source == "template" {
+				item.TemplateIndex = index
+			}
 			if l.Filter.SatisfiedBy(item) {
 				l.Items = append(l.Items, item)
 			}
@@ -73,3 +144,37 @@ func (l *ResourceList) appendItems(source, itemsField string, inputs ...[]byte)
 
 	return nil
 }
+
+// templateObjectLines returns the line numbers at which the entries of the
+// top-level "objects" list start in given YAML template. For templates in
+// another format (e.g. JSON), the result is empty.
+func templateObjectLines(content []byte) []int {
+	lines := []int{}
+	inObjects := false
+	indent := -1
+	for n, line := range strings.Split(string(content), "\n") {
+		if !inObjects {
+			inObjects = strings.HasPrefix(line, "objects:")
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		if len(strings.TrimSpace(trimmed)) == 0 || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lineIndent := len(line) - len(trimmed)
+		isEntry := strings.HasPrefix(trimmed, "- ") || strings.TrimSpace(trimmed) == "-"
+		if lineIndent == 0 && !isEntry {
+			// Next top-level key
+			break
+		}
+		if isEntry {
+			if indent < 0 {
+				indent = lineIndent
+			}
+			if lineIndent == indent {
+				lines = append(lines, n+1)
+			}
+		}
+	}
+	return lines
+}
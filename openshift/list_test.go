@@ -1,6 +1,9 @@
 package openshift
 
 import (
+	"io/ioutil"
+	"os"
+	"reflect"
 	"testing"
 )
 
@@ -205,3 +208,126 @@ metadata: {}
 		t.Errorf("No item should have been extracted, got %v items.", len(secretList.Items))
 	}
 }
+
+func TestCheckDuplicates(t *testing.T) {
+	fooTemplate := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    name: foo
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: foo`)
+	barTemplate := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    name: foo
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: bar`)
+
+	filter := &ResourceFilter{
+		Kinds: []string{"DeploymentConfig", "Service"},
+	}
+	list, err := NewTemplateBasedResourceList(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = list.AppendProcessedTemplate("foo.yml", fooTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := list.CheckDuplicates(); err != nil {
+		t.Errorf("Expected no duplicates, got: %s", err)
+	}
+	err = list.AppendProcessedTemplate("bar.yml", barTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = list.CheckDuplicates()
+	if err == nil {
+		t.Fatal("Expected duplicates to be detected")
+	}
+	expected := "Resources must be defined only once, but the following are defined multiple times:\n" +
+		"- DeploymentConfig/foo (in foo.yml, object #1; bar.yml, object #1)"
+	if err.Error() != expected {
+		t.Errorf("Got error:\n%s\nwant:\n%s", err, expected)
+	}
+}
+
+func TestAppendProcessedTemplateRecordsOrigin(t *testing.T) {
+	templateContent := `apiVersion: v1
+kind: Template
+objects:
+# The deployment
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    name: foo
+  spec:
+    triggers:
+    - type: ConfigChange
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: foo
+parameters:
+- name: FOO
+`
+	processedOutput := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: DeploymentConfig
+  metadata:
+    name: foo
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: foo`)
+
+	f, err := ioutil.TempFile("", "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(templateContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if lines := templateObjectLines([]byte(templateContent)); !reflect.DeepEqual(lines, []int{5, 12}) {
+		t.Errorf("Got object lines %v", lines)
+	}
+
+	filter := &ResourceFilter{
+		Kinds: []string{"Service"},
+	}
+	list, err := NewTemplateBasedResourceList(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = list.AppendProcessedTemplate(f.Name(), processedOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Length() != 1 {
+		t.Fatalf("Expected one item, got %d", list.Length())
+	}
+	expected := f.Name() + ":12, object #2"
+	if origin := list.Items[0].Origin(); origin != expected {
+		t.Errorf("Got origin %s, want %s", origin, expected)
+	}
+}
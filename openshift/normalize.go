@@ -0,0 +1,92 @@
+package openshift
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+var (
+	quantityFields = []*regexp.Regexp{
+		regexp.MustCompile("/resources/(limits|requests)/[^/]+$"),
+		regexp.MustCompile("^/spec/hard/[^/]+$"),
+		regexp.MustCompile("^/spec/limits/[0-9]+/(max|min|default|defaultRequest|maxLimitRequestRatio)/[^/]+$"),
+	}
+	// Fields which are strings or int-or-strings in the API, so that a
+	// boolean or number in a template means the same as its string
+	// representation.
+	stringFields = []*regexp.Regexp{
+		regexp.MustCompile("^/spec/ports/[0-9]+/targetPort$"),
+		regexp.MustCompile("^/spec/port/targetPort$"),
+		regexp.MustCompile("/(httpGet|tcpSocket)/port$"),
+		regexp.MustCompile("^/spec/strategy/(rollingParams|rollingUpdate)/(maxSurge|maxUnavailable)$"),
+		regexp.MustCompile("^/spec/(minAvailable|maxUnavailable)$"),
+		regexp.MustCompile("/metadata/(labels|annotations)/[^/]+$"),
+		regexp.MustCompile("/env/[0-9]+/value$"),
+		regexp.MustCompile("^/data/[^/]+$"),
+	}
+	quantityPattern    = regexp.MustCompile("^([+-]?[0-9]*\\.?[0-9]+(?:[eE][+-]?[0-9]+)?)(Ki|Mi|Gi|Ti|Pi|Ei|n|u|m|k|M|G|T|P|E)?$")
+	quantityMultiplier = map[string]*big.Rat{
+		"":   big.NewRat(1, 1),
+		"n":  big.NewRat(1, 1000000000),
+		"u":  big.NewRat(1, 1000000),
+		"m":  big.NewRat(1, 1000),
+		"k":  big.NewRat(1000, 1),
+		"M":  new(big.Rat).SetInt64(1000 * 1000),
+		"G":  new(big.Rat).SetInt64(1000 * 1000 * 1000),
+		"T":  new(big.Rat).SetInt64(1000 * 1000 * 1000 * 1000),
+		"P":  new(big.Rat).SetInt64(1000 * 1000 * 1000 * 1000 * 1000),
+		"E":  new(big.Rat).SetInt64(1000 * 1000 * 1000 * 1000 * 1000 * 1000),
+		"Ki": new(big.Rat).SetInt64(1 << 10),
+		"Mi": new(big.Rat).SetInt64(1 << 20),
+		"Gi": new(big.Rat).SetInt64(1 << 30),
+		"Ti": new(big.Rat).SetInt64(1 << 40),
+		"Pi": new(big.Rat).SetInt64(1 << 50),
+		"Ei": new(big.Rat).SetInt64(1 << 60),
+	}
+)
+
+// normalizedValue returns a canonical representation of the scalar value v
+// found at path, which is used to compare template and platform values.
+// Resource quantities are converted to their exact amount, so that e.g.
+// "500m" equals "0.5" and "1Gi" equals "1024Mi". In string and
+// int-or-string fields (see stringFields), booleans and numbers are
+// converted to strings, so that e.g. 8080 equals "8080" for targetPort and
+// true equals "true" for labels. Numbers are always decoded as float64 from
+// YAML. Other values are returned as-is, so that e.g. replicas: "1" still
+// differs from replicas: 1.
+func normalizedValue(path string, v interface{}) interface{} {
+	for _, f := range quantityFields {
+		if f.MatchString(path) {
+			if q, ok := parseQuantity(fmt.Sprintf("%v", v)); ok {
+				return "quantity:" + q.RatString()
+			}
+		}
+	}
+	for _, f := range stringFields {
+		if f.MatchString(path) {
+			switch vv := v.(type) {
+			case bool:
+				return strconv.FormatBool(vv)
+			case float64:
+				return strconv.FormatFloat(vv, 'f', -1, 64)
+			}
+		}
+	}
+	return v
+}
+
+// parseQuantity parses a Kubernetes resource quantity such as "100Mi",
+// "0.5" or "1e3" into an exact rational number.
+func parseQuantity(s string) (*big.Rat, bool) {
+	matches := quantityPattern.FindStringSubmatch(s)
+	if matches == nil {
+		return nil, false
+	}
+	r, ok := new(big.Rat).SetString(matches[1])
+	if !ok {
+		return nil, false
+	}
+	return r.Mul(r, quantityMultiplier[matches[2]]), true
+}
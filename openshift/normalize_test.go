@@ -0,0 +1,154 @@
+package openshift
+
+import (
+	"testing"
+)
+
+func TestNormalizedValue(t *testing.T) {
+	tests := map[string]struct {
+		path     string
+		a        interface{}
+		b        interface{}
+		expected bool
+	}{
+		"millicores equal fraction": {
+			path:     "/spec/template/spec/containers/0/resources/limits/cpu",
+			a:        "500m",
+			b:        "0.5",
+			expected: true,
+		},
+		"cores equal number": {
+			path:     "/spec/template/spec/containers/0/resources/requests/cpu",
+			a:        "1",
+			b:        float64(1),
+			expected: true,
+		},
+		"binary suffixes are converted": {
+			path:     "/spec/template/spec/containers/0/resources/limits/memory",
+			a:        "1Gi",
+			b:        "1024Mi",
+			expected: true,
+		},
+		"decimal and binary suffixes differ": {
+			path:     "/spec/template/spec/containers/0/resources/limits/memory",
+			a:        "1G",
+			b:        "1Gi",
+			expected: false,
+		},
+		"storage is a quantity": {
+			path:     "/spec/resources/requests/storage",
+			a:        "1Gi",
+			b:        "1073741824",
+			expected: true,
+		},
+		"limit range defaults are quantities": {
+			path:     "/spec/limits/0/defaultRequest/memory",
+			a:        "512Mi",
+			b:        "0.5Gi",
+			expected: true,
+		},
+		"limit range maximums are quantities": {
+			path:     "/spec/limits/1/max/cpu",
+			a:        "2000m",
+			b:        float64(2),
+			expected: true,
+		},
+		"limit range ratios are quantities": {
+			path:     "/spec/limits/0/maxLimitRequestRatio/cpu",
+			a:        "10",
+			b:        "10.0",
+			expected: true,
+		},
+		"quantities outside of resources are not converted": {
+			path:     "/data/memory",
+			a:        "1Gi",
+			b:        "1024Mi",
+			expected: false,
+		},
+		"int-or-string port": {
+			path:     "/spec/ports/0/targetPort",
+			a:        float64(8080),
+			b:        "8080",
+			expected: true,
+		},
+		"int-or-string probe port": {
+			path:     "/spec/template/spec/containers/0/readinessProbe/httpGet/port",
+			a:        float64(8080),
+			b:        "8080",
+			expected: true,
+		},
+		"booleans as strings": {
+			path:     "/metadata/labels/enabled",
+			a:        true,
+			b:        "true",
+			expected: true,
+		},
+		"numbers as env values": {
+			path:     "/spec/template/spec/containers/0/env/1/value",
+			a:        float64(1.5),
+			b:        "1.5",
+			expected: true,
+		},
+		"number type drift": {
+			path:     "/spec/replicas",
+			a:        float64(1),
+			b:        "1",
+			expected: false,
+		},
+		"boolean type drift": {
+			path:     "/spec/test",
+			a:        true,
+			b:        "true",
+			expected: false,
+		},
+		"different strings": {
+			path:     "/spec/host",
+			a:        "foo",
+			b:        "bar",
+			expected: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := normalizedValue(tc.path, tc.a) == normalizedValue(tc.path, tc.b)
+			if actual != tc.expected {
+				t.Errorf(
+					"Got %v, want %v (normalized values: %v and %v)",
+					actual,
+					tc.expected,
+					normalizedValue(tc.path, tc.a),
+					normalizedValue(tc.path, tc.b),
+				)
+			}
+		})
+	}
+}
+
+func TestChangesFromNormalizedValues(t *testing.T) {
+	platformItem := getItem(t, []byte(
+		`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+spec:
+  ports:
+  - port: 8080
+    targetPort: 8080`), "platform")
+	templateItem := getItem(t, []byte(
+		`apiVersion: v1
+kind: Service
+metadata:
+  name: foo
+spec:
+  ports:
+  - port: 8080
+    targetPort: "8080"`), "template")
+	changes, err := templateItem.ChangesFrom(platformItem, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Action != "Noop" {
+		t.Errorf("Platform and template should be in sync, got %s", changes[0].JsonPatches(true))
+	}
+}
@@ -0,0 +1,118 @@
+package openshift
+
+import (
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/opendevstack/tailor/utils"
+)
+
+// TemplateParameter is a parameter declared in a template.
+type TemplateParameter struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Value    string `json:"value"`
+	Generate string `json:"generate"`
+}
+
+// ParamCheck lists the problems found when checking the keys of param files
+// against the parameters declared in a template.
+type ParamCheck struct {
+	Missing    []string
+	Unused     []string
+	Duplicated []string
+}
+
+// ReadTemplateParameters returns the parameters declared in given template.
+func ReadTemplateParameters(filename string) ([]*TemplateParameter, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	t := struct {
+		Parameters []*TemplateParameter `json:"parameters"`
+	}{}
+	err = yaml.Unmarshal(b, &t)
+	if err != nil {
+		return nil, utils.DisplaySyntaxError(b, err)
+	}
+	return t.Parameters, nil
+}
+
+// Status describes where the value of the parameter comes from if it is not
+// given in a param file.
+func (p *TemplateParameter) Status() string {
+	switch {
+	case p.Name == tailorNamespaceParam:
+		return "set by Tailor"
+	case len(p.Generate) > 0:
+		return "generated"
+	case len(p.Value) > 0:
+		return "default " + strconv.Quote(p.Value)
+	case p.Required:
+		return "required"
+	}
+	return "optional"
+}
+
+// needsValue returns true if the template cannot be processed without a
+// value for the parameter.
+func (p *TemplateParameter) needsValue() bool {
+	return p.Required && len(p.Generate) == 0 && len(p.Value) == 0 && p.Name != tailorNamespaceParam
+}
+
+// ParamKeys returns the keys of given param file content in order, including
+// duplicates. Keys are returned without the ".B64" suffix.
+func ParamKeys(input string) ([]string, error) {
+	keys := []string{}
+	err := extractKeyValuePairs(input, func(key, val string) error {
+		keys = append(keys, strings.TrimSuffix(key, ".B64"))
+		return nil
+	}, func(line string) {})
+	return keys, err
+}
+
+// CheckParams compares the keys found in the param files of a template with
+// the parameters declared by the template. Keys set in another way (e.g. via
+// --param) can be passed as externalKeys; they can fill missing values but
+// are never reported as unused.
+func CheckParams(parameters []*TemplateParameter, fileKeys []string, externalKeys []string) *ParamCheck {
+	check := &ParamCheck{Missing: []string{}, Unused: []string{}, Duplicated: []string{}}
+
+	declared := map[string]bool{}
+	for _, p := range parameters {
+		declared[p.Name] = true
+	}
+
+	counts := map[string]int{}
+	for _, k := range fileKeys {
+		counts[k]++
+	}
+	for k, count := range counts {
+		if count > 1 {
+			check.Duplicated = append(check.Duplicated, k)
+		}
+		if !declared[k] {
+			check.Unused = append(check.Unused, k)
+		}
+	}
+
+	for _, p := range parameters {
+		if p.needsValue() && counts[p.Name] == 0 && !utils.Includes(externalKeys, p.Name) {
+			check.Missing = append(check.Missing, p.Name)
+		}
+	}
+
+	sort.Strings(check.Missing)
+	sort.Strings(check.Unused)
+	sort.Strings(check.Duplicated)
+	return check
+}
+
+// OK returns true if no problems were found.
+func (c *ParamCheck) OK() bool {
+	return len(c.Missing) == 0 && len(c.Unused) == 0 && len(c.Duplicated) == 0
+}
@@ -0,0 +1,71 @@
+package openshift
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadTemplateParameters(t *testing.T) {
+	parameters, err := ReadTemplateParameters("../testdata/template-with-params.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := map[string]string{}
+	for _, p := range parameters {
+		actual[p.Name] = p.Status()
+	}
+	expected := map[string]string{
+		"DB_HOST":          "required",
+		"DB_PASSWORD":      "generated",
+		"REPLICAS":         `default "1"`,
+		"LOG_LEVEL":        "optional",
+		"TAILOR_NAMESPACE": "set by Tailor",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Got %v, want %v", actual, expected)
+	}
+}
+
+func TestCheckParams(t *testing.T) {
+	parameters, err := ReadTemplateParameters("../testdata/template-with-params.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileKeys, err := ParamKeys("# Settings\nREPLICAS=2\nOLD_SETTING=foo\nREPLICAS=3\nDB_PASSWORD.B64=c2VjcmV0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		externalKeys []string
+		expected     *ParamCheck
+	}{
+		"without external keys": {
+			externalKeys: []string{},
+			expected: &ParamCheck{
+				Missing:    []string{"DB_HOST"},
+				Unused:     []string{"OLD_SETTING"},
+				Duplicated: []string{"REPLICAS"},
+			},
+		},
+		"with external keys": {
+			externalKeys: []string{"DB_HOST", "UNRELATED"},
+			expected: &ParamCheck{
+				Missing:    []string{},
+				Unused:     []string{"OLD_SETTING"},
+				Duplicated: []string{"REPLICAS"},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := CheckParams(parameters, fileKeys, tc.externalKeys)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Got %+v, want %+v", actual, tc.expected)
+			}
+			if actual.OK() {
+				t.Error("Check should report problems")
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package openshift
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/opendevstack/tailor/utils"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ParamDifference describes a parameter of a template whose value differs
+// between two param files. From or To is nil if the parameter is not set in
+// the respective param file.
+type ParamDifference struct {
+	Template string
+	Name     string
+	From     *string
+	To       *string
+}
+
+// ParseParams returns the key/value pairs of given param file content.
+func ParseParams(input string) (map[string]string, error) {
+	params := map[string]string{}
+	err := extractKeyValuePairs(input, func(key, val string) error {
+		params[key] = val
+		return nil
+	}, func(line string) {})
+	return params, err
+}
+
+// SetParams returns given param file content with the values of the given
+// params replaced. Params which are not present yet are appended. Comments
+// and the order of existing params are kept.
+func SetParams(input string, params map[string]string) (string, error) {
+	set := map[string]bool{}
+	output := ""
+	err := extractKeyValuePairs(input, func(key, val string) error {
+		if newVal, ok := params[key]; ok {
+			val = newVal
+			set[key] = true
+		}
+		output = output + key + "=" + val + "\n"
+		return nil
+	}, func(line string) {
+		output = output + line + "\n"
+	})
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(output) == "" {
+		output = ""
+	}
+	for _, key := range sortedKeys(params) {
+		if !set[key] {
+			output = output + key + "=" + params[key] + "\n"
+		}
+	}
+	return output, nil
+}
+
+// RemoveParams returns given param file content without the given params.
+// Comments and the order of the remaining params are kept.
+func RemoveParams(input string, names []string) (string, error) {
+	output := ""
+	err := extractKeyValuePairs(input, func(key, val string) error {
+		if !utils.Includes(names, strings.TrimSuffix(key, ".B64")) {
+			output = output + key + "=" + val + "\n"
+		}
+		return nil
+	}, func(line string) {
+		output = output + line + "\n"
+	})
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(output) == "" {
+		output = ""
+	}
+	return output, nil
+}
+
+// CompareParams returns the parameters of the template which are set to
+// different values (or only set) in one of the given param sets, ordered by
+// name.
+func CompareParams(template string, from map[string]string, to map[string]string) []*ParamDifference {
+	names := map[string]bool{}
+	for k := range from {
+		names[k] = true
+	}
+	for k := range to {
+		names[k] = true
+	}
+	sortedNames := []string{}
+	for k := range names {
+		sortedNames = append(sortedNames, k)
+	}
+	sort.Strings(sortedNames)
+
+	differences := []*ParamDifference{}
+	for _, name := range sortedNames {
+		fromVal, inFrom := from[name]
+		toVal, inTo := to[name]
+		if inFrom && inTo && fromVal == toVal {
+			continue
+		}
+		d := &ParamDifference{Template: template, Name: name}
+		if inFrom {
+			d.From = &fromVal
+		}
+		if inTo {
+			d.To = &toVal
+		}
+		differences = append(differences, d)
+	}
+	return differences
+}
+
+// CompareDesiredStates compares the desired state of the source namespace
+// with the desired state of the target namespace. References to the source
+// namespace are rewritten first (see PrepareForClone) so that they do not
+// show up as differences. Items only present in the source are returned as
+// creates, items only present in the target as deletes, and differing items
+// as updates, with DesiredState being the source and CurrentState the target.
+func CompareDesiredStates(from *ResourceList, fromNamespace string, to *ResourceList, toNamespace string) []*Change {
+	changes := []*Change{}
+	for _, toItem := range to.Items {
+		toItem.removeInstanceSpecificFields()
+	}
+	for _, fromItem := range from.Items {
+		fromItem.PrepareForClone(fromNamespace, toNamespace)
+		change := &Change{
+			Kind:         fromItem.Kind,
+			Name:         fromItem.Name,
+			DesiredState: fromItem.YamlConfig(),
+			Origin:       fromItem.Origin(),
+		}
+		toItem, err := to.getItem(fromItem.Kind, fromItem.Name)
+		if err != nil {
+			change.Action = "Create"
+		} else {
+			change.CurrentState = toItem.YamlConfig()
+			if change.CurrentState == change.DesiredState {
+				continue
+			}
+			change.Action = "Update"
+		}
+		changes = append(changes, change)
+	}
+	for _, toItem := range to.Items {
+		if _, err := from.getItem(toItem.Kind, toItem.Name); err == nil {
+			continue
+		}
+		changes = append(changes, &Change{
+			Action:       "Delete",
+			Kind:         toItem.Kind,
+			Name:         toItem.Name,
+			CurrentState: toItem.YamlConfig(),
+			Origin:       toItem.Origin(),
+		})
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return kindOrder[changes[i].Kind] < kindOrder[changes[j].Kind]
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// PromotionDiff returns the unified diff between the desired state of the
+// target namespace and the desired state of the source namespace.
+func (c *Change) PromotionDiff(fromNamespace string, toNamespace string) string {
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(c.CurrentState),
+		B:        difflib.SplitLines(c.DesiredState),
+		FromFile: "Desired State (" + toNamespace + ")",
+		ToFile:   "Desired State (" + fromNamespace + ")",
+		Context:  3,
+	}
+	text, _ := difflib.GetUnifiedDiffString(diff)
+	return text
+}
@@ -0,0 +1,157 @@
+package openshift
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetParams(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		params   map[string]string
+		expected string
+	}{
+		"replace existing value": {
+			input:    "# Database\nDB_HOST=db\nDB_PORT=5432\n",
+			params:   map[string]string{"DB_HOST": "db.prod"},
+			expected: "# Database\nDB_HOST=db.prod\nDB_PORT=5432\n",
+		},
+		"append new value": {
+			input:    "DB_HOST=db\n",
+			params:   map[string]string{"REPLICAS": "3", "CPU": "1"},
+			expected: "DB_HOST=db\nCPU=1\nREPLICAS=3\n",
+		},
+		"empty input": {
+			input:    "",
+			params:   map[string]string{"DB_HOST": "db"},
+			expected: "DB_HOST=db\n",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := SetParams(tc.input, tc.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expected {
+				t.Errorf("Got:\n%s\nwant:\n%s", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCompareParams(t *testing.T) {
+	from := map[string]string{"A": "1", "B": "2", "C": "3"}
+	to := map[string]string{"A": "1", "B": "20", "D": "4"}
+	differences := CompareParams("foo.yml", from, to)
+	expected := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{"B", "2", "20"},
+		{"C", "3", "<unset>"},
+		{"D", "<unset>", "4"},
+	}
+	if len(differences) != len(expected) {
+		t.Fatalf("Got %d differences, want %d", len(differences), len(expected))
+	}
+	value := func(v *string) string {
+		if v == nil {
+			return "<unset>"
+		}
+		return *v
+	}
+	for i, e := range expected {
+		d := differences[i]
+		if d.Template != "foo.yml" || d.Name != e.name || value(d.From) != e.from || value(d.To) != e.to {
+			t.Errorf("Got %s %s: %s → %s, want %s: %s → %s", d.Template, d.Name, value(d.From), value(d.To), e.name, e.from, e.to)
+		}
+	}
+}
+
+func TestCompareDesiredStates(t *testing.T) {
+	fromInput := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: same
+  data:
+    url: http://api.foo-test.svc
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: changed
+  data:
+    replicas: "2"
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: new
+  data: {}
+`)
+	toInput := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: same
+  data:
+    url: http://api.foo-prod.svc
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: changed
+  data:
+    replicas: "3"
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: old
+  data: {}
+`)
+	filter, err := NewResourceFilter("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, err := NewTemplateBasedResourceList(filter, fromInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := NewTemplateBasedResourceList(filter, toInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := CompareDesiredStates(from, "foo-test", to, "foo-prod")
+	actual := []string{}
+	for _, c := range changes {
+		actual = append(actual, c.Action+" "+c.ItemName())
+	}
+	expected := []string{"Update cm/changed", "Create cm/new", "Delete cm/old"}
+	if strings.Join(actual, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("Got %v, want %v", actual, expected)
+	}
+
+	diff := changes[0].PromotionDiff("foo-test", "foo-prod")
+	if !strings.Contains(diff, "-  replicas: \"3\"") || !strings.Contains(diff, "+  replicas: \"2\"") {
+		t.Errorf("Unexpected diff:\n%s", diff)
+	}
+}
+
+func TestRemoveParams(t *testing.T) {
+	input := "# Database\nDB_HOST=db\nDB_PASSWORD=secret\nTOKEN.B64=Zm9v\n"
+	actual, err := RemoveParams(input, []string{"DB_PASSWORD", "TOKEN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Database\nDB_HOST=db\n"
+	if actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+}
@@ -0,0 +1,228 @@
+package openshift
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/opendevstack/tailor/utils"
+)
+
+const (
+	// renameSimilarityThreshold is the minimum similarity between a deleted
+	// and a created resource for them to be considered a rename.
+	renameSimilarityThreshold = 0.8
+)
+
+var (
+	// statefulKinds lists kinds whose data is lost when they are deleted.
+	statefulKinds = []string{"PersistentVolumeClaim"}
+)
+
+// Rename pairs the deletion of a resource with the creation of a very
+// similar resource of the same kind, which is most likely the result of
+// renaming the resource in the template. As OpenShift does not support
+// renaming, the changes are still applied as delete and create.
+type Rename struct {
+	Kind       string
+	From       string
+	To         string
+	Similarity float64
+	Delete     *Change
+	Create     *Change
+}
+
+// ItemName returns the short kind together with the old and the new name.
+func (r *Rename) ItemName() string {
+	return kindToShortMapping[r.Kind] + "/" + r.From + " → " + r.To
+}
+
+// ItemNameWithOrigin returns the item name, followed by the origin of the
+// new resource if known.
+func (r *Rename) ItemNameWithOrigin() string {
+	if len(r.Create.Origin) == 0 {
+		return r.ItemName()
+	}
+	return r.ItemName() + " (" + r.Create.Origin + ")"
+}
+
+// Stateful returns true if data is lost by deleting the old resource.
+func (r *Rename) Stateful() bool {
+	for _, k := range statefulKinds {
+		if k == r.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the unified diff between the old and the new resource.
+func (r *Rename) Diff() string {
+	return r.change().Diff()
+}
+
+// SecretDiff returns the diff between the decoded data of the old and the
+// new secret, see Change.SecretDiff.
+func (r *Rename) SecretDiff(reveal bool) string {
+	c := r.change()
+	c.Patches = []*jsonPatch{&jsonPatch{Op: "replace", Path: secretDataPath}}
+	return c.SecretDiff(reveal)
+}
+
+func (r *Rename) change() *Change {
+	return &Change{
+		Action:       "Update",
+		Kind:         r.Kind,
+		Name:         r.To,
+		CurrentState: r.Delete.CurrentState,
+		DesiredState: r.Create.DesiredState,
+	}
+}
+
+// IsRenamed returns true if change is part of a detected rename.
+func (c *Changeset) IsRenamed(change *Change) bool {
+	for _, r := range c.Rename {
+		if r.Delete == change || r.Create == change {
+			return true
+		}
+	}
+	return false
+}
+
+// StatefulRenames returns the renames which lose data when applied.
+func (c *Changeset) StatefulRenames() []*Rename {
+	renames := []*Rename{}
+	for _, r := range c.Rename {
+		if r.Stateful() {
+			renames = append(renames, r)
+		}
+	}
+	return renames
+}
+
+// detectRenames pairs pending deletes and creates of the same kind if
+// their configuration is similar enough, preferring the most similar pairs.
+func (c *Changeset) detectRenames() {
+	candidates := []*Rename{}
+	for _, d := range c.Delete {
+		for _, cr := range c.Create {
+			if d.Kind != cr.Kind {
+				continue
+			}
+			similarity := renameSimilarity(d, cr)
+			if similarity >= renameSimilarityThreshold {
+				candidates = append(candidates, &Rename{
+					Kind:       d.Kind,
+					From:       d.Name,
+					To:         cr.Name,
+					Similarity: similarity,
+					Delete:     d,
+					Create:     cr,
+				})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+
+	paired := map[*Change]bool{}
+	for _, r := range candidates {
+		if paired[r.Delete] || paired[r.Create] {
+			continue
+		}
+		paired[r.Delete] = true
+		paired[r.Create] = true
+		c.Rename = append(c.Rename, r)
+	}
+	sort.Slice(c.Rename, func(i, j int) bool {
+		if c.Rename[i].Kind != c.Rename[j].Kind {
+			return kindOrder[c.Rename[i].Kind] < kindOrder[c.Rename[j].Kind]
+		}
+		return c.Rename[i].From < c.Rename[j].From
+	})
+}
+
+// renameSimilarity returns a value between 0 and 1 describing how similar
+// the deleted and the created resource are, based on the share of fields
+// (path and value) both have in common. Occurrences of the old name are
+// replaced by the new name first, as names are typically repeated in
+// labels, selectors etc.
+func renameSimilarity(deleteChange, createChange *Change) float64 {
+	current := renameComparableFields(deleteChange.CurrentState, func(s string) string {
+		return renamedValue(s, deleteChange.Name, createChange.Name)
+	})
+	desired := renameComparableFields(createChange.DesiredState, func(s string) string {
+		return s
+	})
+	if len(current)+len(desired) == 0 {
+		return 0
+	}
+	common := 0
+	for f := range current {
+		if desired[f] {
+			common++
+		}
+	}
+	return float64(2*common) / float64(len(current)+len(desired))
+}
+
+// renamedValue replaces occurrences of oldName in s by newName. Only whole
+// segments (delimited by "-", ".", "/" or ":") are replaced, so that short
+// names such as "db" do not change unrelated values such as "dbhost".
+func renamedValue(s string, oldName string, newName string) string {
+	isDelimiter := func(c byte) bool {
+		return strings.IndexByte("-./:", c) >= 0
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		end := i + len(oldName)
+		if strings.HasPrefix(s[i:], oldName) &&
+			(i == 0 || isDelimiter(s[i-1])) &&
+			(end == len(s) || isDelimiter(s[end])) {
+			b.WriteString(newName)
+			i = end
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// renameComparableFields returns all leaf fields of the YAML config in the
+// form "path=value", except those identifying the resource. Keys and string
+// values are passed through rename.
+func renameComparableFields(config string, rename func(string) string) map[string]bool {
+	m := map[string]interface{}{}
+	_ = yaml.Unmarshal([]byte(config), &m)
+	fields := map[string]bool{}
+	collectLeafFields(m, "", rename, fields)
+	for _, f := range []string{"/apiVersion", "/kind", "/metadata/name"} {
+		for k := range fields {
+			if strings.HasPrefix(k, f+"=") {
+				delete(fields, k)
+			}
+		}
+	}
+	return fields
+}
+
+func collectLeafFields(v interface{}, pointer string, rename func(string) string, fields map[string]bool) {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, child := range vv {
+			collectLeafFields(child, pointer+"/"+utils.JSONPointerPath(rename(k)), rename, fields)
+		}
+	case []interface{}:
+		for i, child := range vv {
+			collectLeafFields(child, pointer+"/"+strconv.Itoa(i), rename, fields)
+		}
+	case string:
+		fields[pointer+"="+rename(vv)] = true
+	default:
+		fields[fmt.Sprintf("%s=%v", pointer, vv)] = true
+	}
+}
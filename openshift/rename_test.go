@@ -0,0 +1,170 @@
+package openshift
+
+import (
+	"testing"
+)
+
+func TestDetectRenames(t *testing.T) {
+	platformInput := []byte(
+		`kind: Template
+apiVersion: v1
+objects:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    labels:
+      app: foo
+    name: foo
+  data:
+    a: "1"
+    b: "2"
+    c: "3"
+- apiVersion: v1
+  kind: PersistentVolumeClaim
+  metadata:
+    name: foo-data
+  spec:
+    accessModes:
+    - ReadWriteOnce
+    resources:
+      requests:
+        storage: 1Gi
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: unrelated
+  data:
+    x: "1"`)
+
+	templateInput := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    labels:
+      app: bar
+    name: bar
+  data:
+    a: "1"
+    b: "2"
+    c: "3"
+- apiVersion: v1
+  kind: PersistentVolumeClaim
+  metadata:
+    name: bar-data
+  spec:
+    accessModes:
+    - ReadWriteOnce
+    resources:
+      requests:
+        storage: 1Gi
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: other
+  data:
+    y: "2"
+    z: "3"`)
+
+	filter := &ResourceFilter{
+		Kinds: []string{"ConfigMap", "PersistentVolumeClaim"},
+	}
+	changeset := getChangeset(t, filter, platformInput, templateInput, false, []string{})
+
+	if len(changeset.Delete) != 3 || len(changeset.Create) != 3 {
+		t.Fatalf("Expected 3 deletes and 3 creates, got %d and %d", len(changeset.Delete), len(changeset.Create))
+	}
+	if len(changeset.Rename) != 2 {
+		t.Fatalf("Expected 2 renames, got %d", len(changeset.Rename))
+	}
+
+	cmRename := changeset.Rename[0]
+	if cmRename.Kind != "ConfigMap" || cmRename.From != "foo" || cmRename.To != "bar" {
+		t.Errorf("Got rename %s", cmRename.ItemName())
+	}
+	if cmRename.Stateful() {
+		t.Errorf("ConfigMap should not be stateful")
+	}
+
+	pvcRename := changeset.Rename[1]
+	if pvcRename.Kind != "PersistentVolumeClaim" || pvcRename.From != "foo-data" || pvcRename.To != "bar-data" {
+		t.Errorf("Got rename %s", pvcRename.ItemName())
+	}
+	if !pvcRename.Stateful() {
+		t.Errorf("PersistentVolumeClaim should be stateful")
+	}
+
+	statefulRenames := changeset.StatefulRenames()
+	if len(statefulRenames) != 1 || statefulRenames[0] != pvcRename {
+		t.Errorf("Expected only the PVC rename to be stateful, got %d", len(statefulRenames))
+	}
+
+	for _, c := range changeset.Delete {
+		if c.Name == "unrelated" && changeset.IsRenamed(c) {
+			t.Errorf("Unrelated config map should not be part of a rename")
+		}
+	}
+}
+
+func TestRenamedValue(t *testing.T) {
+	tests := map[string]string{
+		"db":              "cache",
+		"db-data":         "cache-data",
+		"db.svc.local":    "cache.svc.local",
+		"/var/lib/db":     "/var/lib/cache",
+		"registry/db:1.0": "registry/cache:1.0",
+		"db-db":           "cache-cache",
+		"dbhost":          "dbhost",
+		"mydb":            "mydb",
+		"admin":           "admin",
+	}
+	for value, expected := range tests {
+		if actual := renamedValue(value, "db", "cache"); actual != expected {
+			t.Errorf("Got %s for %s, want %s", actual, value, expected)
+		}
+	}
+	if actual := renamedValue("db-data", "db", "db-2"); actual != "db-2-data" {
+		t.Errorf("Got %s, want db-2-data", actual)
+	}
+}
+
+func TestRenameSimilarityWithShortName(t *testing.T) {
+	deleteChange := &Change{
+		Kind: "ConfigMap",
+		Name: "db",
+		CurrentState: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    app: db
+  name: db
+data:
+  host: dbhost
+  mode: mydb
+  url: jdbc:dbdriver://dbhost
+  user: admin`,
+	}
+	createChange := &Change{
+		Kind: "ConfigMap",
+		Name: "cache",
+		DesiredState: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    app: cache
+  name: cache
+data:
+  host: cachehost
+  mode: mycache
+  url: jdbc:cachedriver://cachehost
+  user: admin`,
+	}
+	// Only the label and the user are the same, the name is merely part of
+	// the other values.
+	similarity := renameSimilarity(deleteChange, createChange)
+	if similarity != 0.4 {
+		t.Errorf("Got similarity %v, want 0.4", similarity)
+	}
+}
@@ -0,0 +1,168 @@
+package openshift
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/opendevstack/tailor/cli"
+	"github.com/opendevstack/tailor/utils"
+)
+
+const (
+	// IgnoreAction removes the field before comparison as it is managed by
+	// the platform.
+	IgnoreAction = "ignore"
+	// RecreateAction deletes and creates the resource if the field changes
+	// as it cannot be modified.
+	RecreateAction = "recreate-on-change"
+	// PreserveAction stores the template value in an annotation as the
+	// platform modifies the field.
+	PreserveAction = "preserve-original-value"
+
+	builtinRuleSource = "built-in"
+)
+
+var (
+	fieldRules = defaultFieldRules()
+)
+
+// FieldRule describes how a field of a resource needs to be treated during
+// comparison. A rule applies to all kinds if Kind is empty. The field is
+// identified either by a JSON pointer (Path) or by a regular expression
+// matching JSON pointers (Pattern).
+type FieldRule struct {
+	Kind    string `json:"kind,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Action  string `json:"action"`
+	Source  string `json:"-"`
+	regex   *regexp.Regexp
+}
+
+type fieldRulesFile struct {
+	Rules []*FieldRule `json:"rules"`
+}
+
+func defaultFieldRules() []*FieldRule {
+	rules := []*FieldRule{
+		&FieldRule{Path: "/metadata/generation", Action: IgnoreAction},
+		&FieldRule{Path: "/metadata/creationTimestamp", Action: IgnoreAction},
+		&FieldRule{Path: "/spec/tags", Action: IgnoreAction},
+		&FieldRule{Path: "/status", Action: IgnoreAction},
+		&FieldRule{Path: "/spec/volumeName", Action: IgnoreAction},
+		&FieldRule{Path: "/spec/template/metadata/creationTimestamp", Action: IgnoreAction},
+		&FieldRule{Pattern: "^/spec/triggers/[0-9]*/imageChangeParams/lastTriggeredImage", Action: IgnoreAction},
+		&FieldRule{Kind: "PersistentVolumeClaim", Path: "/spec/accessModes", Action: RecreateAction},
+		&FieldRule{Kind: "PersistentVolumeClaim", Path: "/spec/storageClassName", Action: RecreateAction},
+		&FieldRule{Kind: "PersistentVolumeClaim", Path: "/spec/resources/requests/storage", Action: RecreateAction},
+		&FieldRule{Kind: "Route", Path: "/spec/host", Action: RecreateAction},
+		&FieldRule{Kind: "Secret", Path: "/type", Action: RecreateAction},
+		&FieldRule{Pattern: "/spec/template/spec/containers/[0-9]+/image$", Action: PreserveAction},
+	}
+	for _, r := range rules {
+		r.Source = builtinRuleSource
+		_ = r.compile()
+	}
+	return rules
+}
+
+// LoadFieldRules reads rules from given file and adds them to the built-in
+// rules. The file is expected to look like:
+//
+//	rules:
+//	- kind: DeploymentConfig
+//	  path: /spec/replicas
+//	  action: ignore
+func LoadFieldRules(filename string) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	f := &fieldRulesFile{}
+	err = yaml.Unmarshal(b, f)
+	if err != nil {
+		return utils.DisplaySyntaxError(b, err)
+	}
+	rules := defaultFieldRules()
+	for i, r := range f.Rules {
+		r.Source = filename
+		if len(r.Kind) > 0 {
+			kind, ok := KindMapping[strings.ToLower(r.Kind)]
+			if !ok {
+				return fmt.Errorf("Rule #%d: unknown kind %s", i+1, r.Kind)
+			}
+			r.Kind = kind
+		}
+		err := r.compile()
+		if err != nil {
+			return fmt.Errorf("Rule #%d: %s", i+1, err)
+		}
+		cli.DebugMsg("Loaded rule", r.Action, r.Target(), "for kind", r.Kind)
+		rules = append(rules, r)
+	}
+	fieldRules = rules
+	return nil
+}
+
+// FieldRules returns all active rules.
+func FieldRules() []*FieldRule {
+	return fieldRules
+}
+
+// Target returns the path or pattern the rule is applied to.
+func (r *FieldRule) Target() string {
+	if len(r.Path) > 0 {
+		return r.Path
+	}
+	return r.Pattern
+}
+
+func (r *FieldRule) compile() error {
+	if r.Action != IgnoreAction && r.Action != RecreateAction && r.Action != PreserveAction {
+		return fmt.Errorf(
+			"action must be one of %s, %s, %s",
+			IgnoreAction,
+			RecreateAction,
+			PreserveAction,
+		)
+	}
+	if len(r.Path) > 0 && len(r.Pattern) > 0 {
+		return errors.New("path and pattern cannot be given both")
+	}
+	if len(r.Path) > 0 {
+		r.regex = regexp.MustCompile("^" + regexp.QuoteMeta(r.Path) + "$")
+		return nil
+	}
+	if len(r.Pattern) > 0 {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return err
+		}
+		r.regex = re
+		return nil
+	}
+	return errors.New("either path or pattern is required")
+}
+
+func (r *FieldRule) appliesTo(kind string) bool {
+	return len(r.Kind) == 0 || r.Kind == kind
+}
+
+func (r *FieldRule) matches(path string) bool {
+	return r.regex.MatchString(path)
+}
+
+// rulesFor returns the rules for given kind and action.
+func rulesFor(kind string, action string) []*FieldRule {
+	rules := []*FieldRule{}
+	for _, r := range fieldRules {
+		if r.Action == action && r.appliesTo(kind) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,166 @@
+package openshift
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/opendevstack/tailor/utils"
+)
+
+func TestLoadFieldRules(t *testing.T) {
+	defer func() { fieldRules = defaultFieldRules() }()
+
+	filename := writeRulesFile(t, `rules:
+- kind: dc
+  path: /spec/replicas
+  action: ignore
+- kind: Service
+  pattern: ^/spec/ports/[0-9]+/nodePort$
+  action: ignore
+- kind: ConfigMap
+  path: /data/immutable
+  action: recreate-on-change`)
+	defer os.Remove(filename)
+
+	err := LoadFieldRules(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(FieldRules()) != len(defaultFieldRules())+3 {
+		t.Errorf("Rules from file should be added to built-in rules, got %d rules", len(FieldRules()))
+	}
+
+	platformItem := getItem(t, getTemplateDeploymentConfig([]byte("latest")), "platform")
+	templateItem := getItem(t, getTemplateDeploymentConfig([]byte("latest")), "template")
+	templateItem.Config["spec"].(map[string]interface{})["replicas"] = float64(3)
+	changes, err := templateItem.ChangesFrom(platformItem, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes[0].Action != "Noop" {
+		t.Errorf("Ignored field should not cause drift, got %s", changes[0].JsonPatches(true))
+	}
+
+	if !(&ResourceItem{Kind: "ConfigMap"}).isImmutableField("/data/immutable") {
+		t.Errorf("/data/immutable should be immutable for ConfigMap")
+	}
+	if (&ResourceItem{Kind: "Secret"}).isImmutableField("/data/immutable") {
+		t.Errorf("/data/immutable should not be immutable for Secret")
+	}
+}
+
+func TestLoadFieldRulesInvalid(t *testing.T) {
+	defer func() { fieldRules = defaultFieldRules() }()
+
+	tests := map[string]string{
+		"unknown action": `rules:
+- path: /spec/replicas
+  action: foo`,
+		"unknown kind": `rules:
+- kind: foo
+  path: /spec/replicas
+  action: ignore`,
+		"missing path": `rules:
+- action: ignore`,
+		"path and pattern": `rules:
+- path: /spec/replicas
+  pattern: ^/spec/replicas$
+  action: ignore`,
+		"invalid pattern": `rules:
+- pattern: ^/spec/(replicas
+  action: ignore`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			filename := writeRulesFile(t, content)
+			defer os.Remove(filename)
+			if err := LoadFieldRules(filename); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadFieldRulesOverlappingIgnore(t *testing.T) {
+	defer func() { fieldRules = defaultFieldRules() }()
+
+	filename := writeRulesFile(t, `rules:
+- kind: dc
+  pattern: ^/spec/triggers/[0-9]*/imageChangeParams/lastTriggeredImage
+  action: ignore`)
+	defer os.Remove(filename)
+	if err := LoadFieldRules(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	item := getItem(t, []byte(`apiVersion: v1
+kind: DeploymentConfig
+metadata:
+  name: foo
+spec:
+  triggers:
+  - imageChangeParams:
+      automatic: true
+      lastTriggeredImage: foo@sha256:123
+    type: ImageChange`), "platform")
+	expected := []string{
+		"/spec/triggers/0/imageChangeParams",
+		"/spec/triggers/0/imageChangeParams/automatic",
+		"/spec/triggers/0/type",
+	}
+	for _, path := range expected {
+		if !utils.Includes(item.Paths, path) {
+			t.Errorf("Path %s should be kept, got %v", path, item.Paths)
+		}
+	}
+	if utils.Includes(item.Paths, "/spec/triggers/0/imageChangeParams/lastTriggeredImage") {
+		t.Errorf("Ignored path should be removed, got %v", item.Paths)
+	}
+}
+
+func TestLoadFieldRulesPreserveNonString(t *testing.T) {
+	defer func() { fieldRules = defaultFieldRules() }()
+
+	filename := writeRulesFile(t, `rules:
+- kind: dc
+  path: /spec/replicas
+  action: preserve-original-value`)
+	defer os.Remove(filename)
+	if err := LoadFieldRules(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	templateItem := getItem(t, getTemplateDeploymentConfig([]byte("latest")), "template")
+	annotation := templateItem.Config["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})["original-values.tailor.io/spec.replicas"]
+	if annotation != "1" {
+		t.Errorf("Original value should be stored as string, got %#v", annotation)
+	}
+
+	platformConfig := strings.Replace(
+		string(getTemplateDeploymentConfig([]byte("latest"))),
+		"  name: foo\nspec:\n  replicas: 1",
+		"  name: foo\n  annotations:\n    original-values.tailor.io/spec.replicas: \"1\"\nspec:\n  replicas: 3",
+		1,
+	)
+	platformItem := getItem(t, []byte(platformConfig), "platform")
+	replicas := platformItem.Config["spec"].(map[string]interface{})["replicas"]
+	if replicas != float64(1) {
+		t.Errorf("Original value should be restored as number, got %#v", replicas)
+	}
+}
+
+func writeRulesFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "tailor-rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = f.WriteString(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
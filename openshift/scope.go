@@ -0,0 +1,127 @@
+package openshift
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/opendevstack/tailor/cli"
+)
+
+var (
+	// clusterScopedKinds maps kinds which are not namespaced to the resource
+	// name used to check permissions.
+	clusterScopedKinds = map[string]string{
+		"Project":              "projects",
+		"ClusterResourceQuota": "clusterresourcequotas",
+		"ClusterRole":          "clusterroles",
+		"ClusterRoleBinding":   "clusterrolebindings",
+	}
+	changeActionVerbs = map[string]string{
+		"Create": "create",
+		"Update": "patch",
+		"Delete": "delete",
+	}
+)
+
+// IsClusterScoped returns true if resources of given kind do not belong to
+// a namespace.
+func IsClusterScoped(kind string) bool {
+	_, ok := clusterScopedKinds[kind]
+	return ok
+}
+
+// ClusterScoped returns true if the change affects a cluster-scoped resource.
+func (c *Change) ClusterScoped() bool {
+	return IsClusterScoped(c.Kind)
+}
+
+// ClusterScopedItems returns all items of the list which are cluster-scoped.
+func (l *ResourceList) ClusterScopedItems() []*ResourceItem {
+	items := []*ResourceItem{}
+	for _, item := range l.Items {
+		if IsClusterScoped(item.Kind) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ClusterScopedChanges returns all creates, updates and deletes of
+// cluster-scoped resources.
+func (c *Changeset) ClusterScopedChanges() []*Change {
+	changes := []*Change{}
+	for _, group := range [][]*Change{c.Create, c.Update, c.Delete} {
+		for _, change := range group {
+			if change.ClusterScoped() {
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes
+}
+
+// ExportClusterScopedResources exports the current state of given
+// cluster-scoped items. As exporting all resources of a cluster-scoped kind
+// would include resources not managed by the templates, they are exported
+// by name. Items which do not exist yet are skipped.
+func ExportClusterScopedResources(items []*ResourceItem) ([][]byte, error) {
+	outputs := [][]byte{}
+	for _, item := range items {
+		target := item.Kind + "/" + item.Name
+		args := []string{"export", target, "--output=yaml", "--as-template=tailor"}
+		cmd := cli.ExecOcCmd(args, "", "")
+		outBytes, errBytes, err := cli.RunCmd(cmd)
+		if err != nil {
+			ret := string(errBytes)
+			if strings.Contains(ret, "not found") {
+				cli.DebugMsg("No", target, "resource found.")
+				continue
+			}
+			return outputs, fmt.Errorf(
+				"Failed to export %s resource.\n"+
+					"%s\n",
+				target,
+				ret,
+			)
+		}
+		outputs = append(outputs, outBytes)
+	}
+	return outputs, nil
+}
+
+// CheckClusterPermissions verifies that the current user is allowed to
+// apply given changes of cluster-scoped resources, and returns an error
+// listing all missing permissions otherwise.
+func CheckClusterPermissions(changes []*Change) error {
+	required := map[string]bool{}
+	for _, change := range changes {
+		resource, ok := clusterScopedKinds[change.Kind]
+		if !ok {
+			continue
+		}
+		required[changeActionVerbs[change.Action]+" "+resource] = true
+	}
+	permissions := []string{}
+	for p := range required {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+
+	missing := []string{}
+	for _, p := range permissions {
+		args := append([]string{"auth", "can-i"}, strings.Split(p, " ")...)
+		cmd := cli.ExecPlainOcCmd(args)
+		outBytes, _, err := cli.RunCmd(cmd)
+		if err != nil || strings.TrimSpace(string(outBytes)) != "yes" {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf(
+			"Refusing to apply cluster-wide changes as the current user is not allowed to: %s",
+			strings.Join(missing, ", "),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package openshift
+
+import (
+	"testing"
+)
+
+func TestConvertToKindsSkipsClusterScopedKinds(t *testing.T) {
+	tests := map[string]string{
+		"":                      "svc,route,dc,bc,is,pvc,template,cm,secret,rolebinding,serviceaccount",
+		"dc,clusterrole":        "DeploymentConfig",
+		"clusterrole,project":   "",
+		"clusterrole/foo":       "",
+		"dc/foo":                "DeploymentConfig",
+		"dc/foo,clusterrole/f*": "DeploymentConfig",
+	}
+	for kindArg, expected := range tests {
+		filter, err := NewResourceFilter(kindArg, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := filter.ConvertToKinds(); actual != expected {
+			t.Errorf("%s: got %s, want %s", kindArg, actual, expected)
+		}
+	}
+}
+
+func TestClusterScopedChanges(t *testing.T) {
+	templateInput := []byte(
+		`kind: List
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: ClusterRole
+  metadata:
+    name: foo-reader
+- apiVersion: v1
+  kind: ClusterRoleBinding
+  metadata:
+    name: foo-reader
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: foo`)
+
+	filter := &ResourceFilter{}
+	templateBasedList, err := NewTemplateBasedResourceList(filter, templateInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := templateBasedList.ClusterScopedItems(); len(items) != 2 {
+		t.Errorf("Expected 2 cluster-scoped items, got %d", len(items))
+	}
+
+	changeset := getChangeset(t, filter, []byte{}, templateInput, false, []string{})
+	if len(changeset.Create) != 3 {
+		t.Fatalf("Expected 3 creates, got %d", len(changeset.Create))
+	}
+	// Cluster-scoped resources are created first
+	if changeset.Create[0].Kind != "ClusterRole" || changeset.Create[1].Kind != "ClusterRoleBinding" {
+		t.Errorf("Got create order %s, %s", changeset.Create[0].Kind, changeset.Create[1].Kind)
+	}
+	changes := changeset.ClusterScopedChanges()
+	if len(changes) != 2 {
+		t.Errorf("Expected 2 cluster-scoped changes, got %d", len(changes))
+	}
+	for _, c := range changes {
+		if !c.ClusterScoped() {
+			t.Errorf("Expected %s to be cluster-scoped", c.ItemName())
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package openshift
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	secretDataPath       = "/data"
+	secretHashPrefixSize = 8
+)
+
+// SecretDiff renders the change of a secret based on the decoded values of
+// its data keys, reporting which keys are added, removed or changed. The
+// values are masked (showing only their length and a hash prefix) unless
+// reveal is true. Changes outside of the data are rendered structurally.
+func (c *Change) SecretDiff(reveal bool) string {
+	current := secretData(c.CurrentState)
+	desired := secretData(c.DesiredState)
+
+	var sb strings.Builder
+	switch c.Action {
+	case "Create":
+		for _, k := range sortedDataKeys(desired) {
+			fmt.Fprintf(&sb, "  + data/%s: %s\n", k, secretValue(desired[k], reveal))
+		}
+	case "Delete":
+		for _, k := range sortedDataKeys(current) {
+			fmt.Fprintf(&sb, "  - data/%s: %s\n", k, secretValue(current[k], reveal))
+		}
+	default:
+		otherPatches := []*jsonPatch{}
+		changedKeys := map[string]bool{}
+		for _, p := range c.Patches {
+			if p.Op == "noop" {
+				continue
+			}
+			if p.Path == secretDataPath {
+				for k := range current {
+					changedKeys[k] = true
+				}
+				for k := range desired {
+					changedKeys[k] = true
+				}
+			} else if strings.HasPrefix(p.Path, secretDataPath+"/") {
+				changedKeys[unescapePointerSegment(strings.TrimPrefix(p.Path, secretDataPath+"/"))] = true
+			} else {
+				otherPatches = append(otherPatches, p)
+			}
+		}
+		keys := []string{}
+		for k := range changedKeys {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			currentVal, inCurrent := current[k]
+			desiredVal, inDesired := desired[k]
+			switch {
+			case inCurrent && inDesired && currentVal == desiredVal:
+				continue
+			case inCurrent && inDesired:
+				fmt.Fprintf(&sb, "  ~ data/%s: %s → %s\n", k, secretValue(currentVal, reveal), secretValue(desiredVal, reveal))
+			case inDesired:
+				fmt.Fprintf(&sb, "  + data/%s: %s\n", k, secretValue(desiredVal, reveal))
+			case inCurrent:
+				fmt.Fprintf(&sb, "  - data/%s: %s\n", k, secretValue(currentVal, reveal))
+			}
+		}
+		if len(otherPatches) > 0 {
+			other := &Change{
+				Action:       c.Action,
+				Kind:         c.Kind,
+				Name:         c.Name,
+				Patches:      otherPatches,
+				CurrentState: c.CurrentState,
+				DesiredState: c.DesiredState,
+			}
+			sb.WriteString(other.StructuralDiff())
+		}
+	}
+	return sb.String()
+}
+
+// secretData returns the base64-encoded data of the secret described by
+// the YAML config.
+func secretData(config string) map[string]string {
+	s := struct {
+		Data map[string]string `json:"data"`
+	}{}
+	_ = yaml.Unmarshal([]byte(config), &s)
+	if s.Data == nil {
+		return map[string]string{}
+	}
+	return s.Data
+}
+
+// MaskedJsonPatches returns the JSON patches of a secret change like
+// JsonPatches, but with the values of data keys masked (showing only their
+// length and a hash prefix) unless reveal is true.
+func (c *Change) MaskedJsonPatches(pretty bool, reveal bool) string {
+	if reveal {
+		return c.JsonPatches(pretty)
+	}
+	return (&Change{Patches: c.maskedPatches()}).JsonPatches(pretty)
+}
+
+func (c *Change) maskedPatches() []*jsonPatch {
+	masked := []*jsonPatch{}
+	for _, p := range c.Patches {
+		maskedPatch := &jsonPatch{Op: p.Op, Path: p.Path, Value: p.Value}
+		switch {
+		case p.Path == secretDataPath:
+			if data, ok := p.Value.(map[string]interface{}); ok {
+				maskedData := map[string]interface{}{}
+				for k, v := range data {
+					maskedData[k] = maskedSecretValue(v)
+				}
+				maskedPatch.Value = maskedData
+			}
+		case strings.HasPrefix(p.Path, secretDataPath+"/"):
+			maskedPatch.Value = maskedSecretValue(p.Value)
+		}
+		masked = append(masked, maskedPatch)
+	}
+	return masked
+}
+
+func maskedSecretValue(v interface{}) interface{} {
+	if encoded, ok := v.(string); ok {
+		return secretValue(encoded, false)
+	}
+	return v
+}
+
+func sortedDataKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// secretValue decodes the base64-encoded value and either masks it, e.g.
+// "(12 bytes, sha256:1a2b3c4d)", or returns it quoted if reveal is true.
+func secretValue(encoded string, reveal bool) string {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "(invalid base64)"
+	}
+	if reveal {
+		return strconv.Quote(string(decoded))
+	}
+	sum := sha256.Sum256(decoded)
+	return fmt.Sprintf(
+		"(%d bytes, sha256:%s)",
+		len(decoded),
+		hex.EncodeToString(sum[:])[:secretHashPrefixSize],
+	)
+}
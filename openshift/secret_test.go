@@ -0,0 +1,113 @@
+package openshift
+
+import (
+	"testing"
+)
+
+func TestSecretDiff(t *testing.T) {
+	// "foo" => Zm9v, "bar" => YmFy, "baz" => YmF6
+	c := &Change{
+		Action: "Update",
+		Kind:   "Secret",
+		Name:   "foo",
+		Patches: []*jsonPatch{
+			&jsonPatch{Op: "replace", Path: "/data/password", Value: "YmFy"},
+			&jsonPatch{Op: "add", Path: "/data/token", Value: "YmF6"},
+			&jsonPatch{Op: "remove", Path: "/data/username"},
+			&jsonPatch{Op: "add", Path: "/metadata/labels", Value: map[string]interface{}{"app": "foo"}},
+		},
+		CurrentState: `apiVersion: v1
+data:
+  password: Zm9v
+  unchanged: Zm9v
+  username: Zm9v
+kind: Secret
+metadata:
+  name: foo
+`,
+		DesiredState: `apiVersion: v1
+data:
+  password: YmFy
+  token: YmF6
+  unchanged: Zm9v
+kind: Secret
+metadata:
+  labels:
+    app: foo
+  name: foo
+`,
+	}
+
+	expected := `  ~ data/password: (3 bytes, sha256:2c26b46b) → (3 bytes, sha256:fcde2b2e)
+  + data/token: (3 bytes, sha256:baa5a096)
+  - data/username: (3 bytes, sha256:2c26b46b)
+  /metadata/labels: (none) → {"app":"foo"}
+`
+	actual := c.SecretDiff(false)
+	if actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+
+	expected = `  ~ data/password: "foo" → "bar"
+  + data/token: "baz"
+  - data/username: "foo"
+  /metadata/labels: (none) → {"app":"foo"}
+`
+	actual = c.SecretDiff(true)
+	if actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+}
+
+func TestSecretDiffCreate(t *testing.T) {
+	c := &Change{
+		Action: "Create",
+		Kind:   "Secret",
+		Name:   "foo",
+		DesiredState: `apiVersion: v1
+data:
+  b: Zm9v
+  a: invalid!
+kind: Secret
+metadata:
+  name: foo
+`,
+	}
+	expected := `  + data/a: (invalid base64)
+  + data/b: (3 bytes, sha256:2c26b46b)
+`
+	actual := c.SecretDiff(false)
+	if actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+}
+
+func TestMaskedJsonPatches(t *testing.T) {
+	// "bar" => YmFy, "baz" => YmF6
+	c := &Change{
+		Action: "Update",
+		Kind:   "Secret",
+		Name:   "foo",
+		Patches: []*jsonPatch{
+			&jsonPatch{Op: "replace", Path: "/data", Value: map[string]interface{}{"password": "YmFy"}},
+			&jsonPatch{Op: "add", Path: "/data/token", Value: "YmF6"},
+			&jsonPatch{Op: "add", Path: "/metadata/labels", Value: map[string]interface{}{"app": "foo"}},
+		},
+	}
+
+	expected := `[{"op":"replace","path":"/data","value":{"password":"(3 bytes, sha256:fcde2b2e)"}},` +
+		`{"op":"add","path":"/data/token","value":"(3 bytes, sha256:baa5a096)"},` +
+		`{"op":"add","path":"/metadata/labels","value":{"app":"foo"}}]`
+	actual := c.MaskedJsonPatches(false, false)
+	if actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+	if c.Patches[1].Value != "YmF6" {
+		t.Errorf("Masking should not modify the patches to apply")
+	}
+
+	actual = c.MaskedJsonPatches(false, true)
+	if actual != c.JsonPatches(false) {
+		t.Errorf("Revealed patches should not be masked, got:\n%s", actual)
+	}
+}
@@ -0,0 +1,199 @@
+package openshift
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	selectorEquals       = "="
+	selectorNotEquals    = "!="
+	selectorIn           = "in"
+	selectorNotIn        = "notin"
+	selectorExists       = "exists"
+	selectorDoesNotExist = "!"
+
+	// annotationRequirementPrefix marks requirements in selectors and
+	// excludes which apply to annotations instead of labels.
+	annotationRequirementPrefix = "annotation:"
+)
+
+var (
+	labelKeyPattern     = regexp.MustCompile(`^([a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?/)?[a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?$`)
+	labelValuePattern   = regexp.MustCompile(`^([a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?)?$`)
+	setRequirementRegex = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+)
+
+// labelRequirement is one comma-separated part of a label selector, e.g.
+// "app=foo", "tier!=frontend", "env in (test, prod)", "release" or "!canary".
+type labelRequirement struct {
+	key      string
+	operator string
+	values   []string
+}
+
+// parseLabelSelector parses a selector in Kubernetes syntax. All
+// requirements of the selector have to be met by matching labels.
+func parseLabelSelector(selector string) ([]*labelRequirement, error) {
+	requirements := []*labelRequirement{}
+	for _, part := range splitSelector(selector) {
+		r, err := parseLabelRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, r)
+	}
+	return requirements, nil
+}
+
+// splitSelector splits selector at commas which are not enclosed in
+// parentheses.
+func splitSelector(selector string) []string {
+	parts := []string{}
+	depth := 0
+	start := 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, selector[start:])
+	return parts
+}
+
+func parseLabelRequirement(expr string) (*labelRequirement, error) {
+	return parseRequirement(expr, true)
+}
+
+// parseAnnotationRequirement parses a requirement like
+// parseLabelRequirement, but allows arbitrary values as annotation values
+// are not restricted. The expression must not contain the "annotation:"
+// prefix.
+func parseAnnotationRequirement(expr string) (*labelRequirement, error) {
+	return parseRequirement(expr, false)
+}
+
+func parseRequirement(expr string, restrictValues bool) (*labelRequirement, error) {
+	expr = strings.TrimSpace(expr)
+	r := &labelRequirement{}
+	switch {
+	case len(expr) == 0:
+		return nil, fmt.Errorf("Invalid selector: empty requirement")
+	case setRequirementRegex.MatchString(expr):
+		matches := setRequirementRegex.FindStringSubmatch(expr)
+		r.key = matches[1]
+		r.operator = matches[2]
+		for _, v := range strings.Split(matches[3], ",") {
+			r.values = append(r.values, strings.TrimSpace(v))
+		}
+	case strings.Contains(expr, "!="):
+		parts := strings.SplitN(expr, "!=", 2)
+		r.key, r.operator, r.values = strings.TrimSpace(parts[0]), selectorNotEquals, []string{strings.TrimSpace(parts[1])}
+	case strings.Contains(expr, "=="):
+		parts := strings.SplitN(expr, "==", 2)
+		r.key, r.operator, r.values = strings.TrimSpace(parts[0]), selectorEquals, []string{strings.TrimSpace(parts[1])}
+	case strings.Contains(expr, "="):
+		parts := strings.SplitN(expr, "=", 2)
+		r.key, r.operator, r.values = strings.TrimSpace(parts[0]), selectorEquals, []string{strings.TrimSpace(parts[1])}
+	case strings.HasPrefix(expr, "!"):
+		r.key, r.operator = strings.TrimSpace(expr[1:]), selectorDoesNotExist
+	default:
+		r.key, r.operator = expr, selectorExists
+	}
+
+	if !labelKeyPattern.MatchString(r.key) {
+		return nil, fmt.Errorf("Invalid selector %s: %q is not a valid key", expr, r.key)
+	}
+	if (r.operator == selectorIn || r.operator == selectorNotIn) && len(r.values) == 1 && len(r.values[0]) == 0 {
+		return nil, fmt.Errorf("Invalid selector %s: %s requires at least one value", expr, r.operator)
+	}
+	for _, v := range r.values {
+		if restrictValues && !labelValuePattern.MatchString(v) {
+			return nil, fmt.Errorf("Invalid selector %s: %q is not a valid label value", expr, v)
+		}
+	}
+	return r, nil
+}
+
+// matches returns true if labels meet the requirement.
+func (r *labelRequirement) matches(labels map[string]interface{}) bool {
+	v, exists := labels[r.key]
+	value := fmt.Sprintf("%v", v)
+	switch r.operator {
+	case selectorExists:
+		return exists
+	case selectorDoesNotExist:
+		return !exists
+	case selectorEquals, selectorIn:
+		return exists && r.hasValue(value)
+	case selectorNotEquals, selectorNotIn:
+		return !exists || !r.hasValue(value)
+	}
+	return false
+}
+
+func (r *labelRequirement) hasValue(value string) bool {
+	for _, v := range r.values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesSelector returns true if the labels of the item meet all
+// requirements of given selector (in Kubernetes syntax).
+func (i *ResourceItem) MatchesSelector(selector string) (bool, error) {
+	requirements, err := parseLabelSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range requirements {
+		if !r.matches(i.Labels) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isAnnotationRequirement returns true if expr has the "annotation:" prefix.
+func isAnnotationRequirement(expr string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(expr)), annotationRequirementPrefix)
+}
+
+// trimAnnotationPrefix removes the "annotation:" prefix from expr.
+func trimAnnotationPrefix(expr string) string {
+	return strings.TrimSpace(expr)[len(annotationRequirementPrefix):]
+}
+
+// LabelSelector returns the selector without its annotation requirements,
+// which is suitable to be passed to oc.
+func LabelSelector(selector string) string {
+	labelRequirements := []string{}
+	for _, part := range splitSelector(selector) {
+		if !isAnnotationRequirement(part) {
+			labelRequirements = append(labelRequirements, part)
+		}
+	}
+	return strings.Join(labelRequirements, ",")
+}
+
+// HasAnnotation returns true if the annotations of the item meet the
+// requirement (without "annotation:" prefix), e.g. "owner=platform-team".
+// Invalid requirements are never met.
+func (i *ResourceItem) HasAnnotation(annotation string) bool {
+	r, err := parseAnnotationRequirement(annotation)
+	if err != nil {
+		return false
+	}
+	return r.matches(i.Annotations)
+}
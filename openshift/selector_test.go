@@ -0,0 +1,153 @@
+package openshift
+
+import (
+	"testing"
+)
+
+func TestMatchesSelector(t *testing.T) {
+	item := &ResourceItem{
+		Labels: map[string]interface{}{
+			"app":                "foo",
+			"tier":               "backend",
+			"version":            float64(2),
+			"example.com/canary": "true",
+		},
+	}
+
+	tests := map[string]bool{
+		"app=foo":                       true,
+		"app==foo":                      true,
+		"app=bar":                       false,
+		"app!=bar":                      true,
+		"app!=foo":                      false,
+		"missing!=foo":                  true,
+		"app in (foo, bar)":             true,
+		"app in (bar,baz)":              false,
+		"app notin (bar, baz)":          true,
+		"app notin (foo)":               false,
+		"missing notin (foo)":           true,
+		"tier":                          true,
+		"missing":                       false,
+		"!missing":                      true,
+		"!tier":                         false,
+		"version=2":                     true,
+		"example.com/canary=true":       true,
+		"app=foo,tier in (backend),!x":  true,
+		"app=foo,tier in (frontend),!x": false,
+	}
+	for selector, expected := range tests {
+		actual, err := item.MatchesSelector(selector)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", selector, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%s: got %v, want %v", selector, actual, expected)
+		}
+	}
+}
+
+func TestParseLabelSelectorErrors(t *testing.T) {
+	invalid := []string{
+		"",
+		"app=foo,",
+		"app in ()",
+		"-app=foo",
+		"app=foo bar",
+		"!",
+	}
+	for _, selector := range invalid {
+		if _, err := parseLabelSelector(selector); err == nil {
+			t.Errorf("Expected %q to be invalid", selector)
+		}
+	}
+}
+
+func TestResourceFilterWithSelector(t *testing.T) {
+	item := &ResourceItem{
+		Kind:   "BuildConfig",
+		Name:   "foo",
+		Labels: map[string]interface{}{"app": "foo", "replicas": float64(1)},
+	}
+
+	filter, err := NewResourceFilter("", "app in (foo,bar),replicas", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filter.SatisfiedBy(item) {
+		t.Errorf("Expected item to satisfy filter %s", filter)
+	}
+
+	filter, err = NewResourceFilter("", "", "app notin (bar,baz)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.SatisfiedBy(item) {
+		t.Errorf("Expected item to be excluded by filter %s", filter)
+	}
+
+	if _, err := NewResourceFilter("", "app in (foo", ""); err == nil {
+		t.Errorf("Expected invalid selector to be rejected")
+	}
+}
+
+func TestResourceFilterWithAnnotations(t *testing.T) {
+	item := &ResourceItem{
+		Kind:   "ConfigMap",
+		Name:   "foo",
+		Labels: map[string]interface{}{"app": "foo"},
+		Annotations: map[string]interface{}{
+			"owner":                      "platform-team",
+			"example.com/config-version": "v1 (legacy)",
+		},
+	}
+
+	tests := map[string]struct {
+		selectorFlag string
+		excludeFlag  string
+		expected     bool
+	}{
+		"included by annotation": {
+			selectorFlag: "app=foo,annotation:owner=platform-team",
+			expected:     true,
+		},
+		"not included by annotation": {
+			selectorFlag: "annotation:owner in (app-team)",
+			expected:     false,
+		},
+		"included by annotation with arbitrary value": {
+			selectorFlag: "annotation:example.com/config-version=v1 (legacy)",
+			expected:     true,
+		},
+		"excluded by annotation": {
+			excludeFlag: "annotation:owner=platform-team",
+			expected:    false,
+		},
+		"excluded by annotation existence": {
+			excludeFlag: "dc,annotation:owner",
+			expected:    false,
+		},
+		"not excluded by other annotation": {
+			excludeFlag: "annotation:owner=app-team,annotation:!owner",
+			expected:    true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter, err := NewResourceFilter("", tc.selectorFlag, tc.excludeFlag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := filter.SatisfiedBy(item); actual != tc.expected {
+				t.Errorf("Got: %v, want: %v. Filter is: %s", actual, tc.expected, filter)
+			}
+		})
+	}
+
+	if selector := LabelSelector("app=foo,annotation:owner=platform-team,tier"); selector != "app=foo,tier" {
+		t.Errorf("Got label selector %s", selector)
+	}
+	if _, err := NewResourceFilter("", "", "annotation:=foo"); err == nil {
+		t.Errorf("Expected invalid annotation exclude to be rejected")
+	}
+}
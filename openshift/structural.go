@@ -0,0 +1,119 @@
+package openshift
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/xeipuuv/gojsonpointer"
+)
+
+const (
+	structuralAbsentValue = "(none)"
+	structuralMaxValueLen = 80
+)
+
+// StructuralDiff renders each patch of the change on one line in the form
+// "path: old → new". Array indices in the path are replaced by a selector
+// such as "containers[name=foo]" where the element has a name, so that it
+// is clear which container, env var etc. is affected. Unchanged parts of
+// the resource are not shown, and large new or old values are collapsed.
+func (c *Change) StructuralDiff() string {
+	current := map[string]interface{}{}
+	desired := map[string]interface{}{}
+	_ = yaml.Unmarshal([]byte(c.CurrentState), &current)
+	_ = yaml.Unmarshal([]byte(c.DesiredState), &desired)
+
+	var sb strings.Builder
+	for _, p := range c.Patches {
+		oldValue := structuralAbsentValue
+		newValue := structuralAbsentValue
+		context := current
+		switch p.Op {
+		case "add":
+			context = desired
+			newValue = structuralValue(p.Value)
+		case "replace":
+			oldValue = structuralValueAt(current, p.Path)
+			newValue = structuralValue(p.Value)
+		case "remove":
+			oldValue = structuralValueAt(current, p.Path)
+		default:
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s: %s → %s\n", structuralPath(p.Path, context), oldValue, newValue)
+	}
+	return sb.String()
+}
+
+// structuralPath replaces array indices in path by a "[name=...]" selector
+// if the element at that index in config has a name.
+func structuralPath(path string, config map[string]interface{}) string {
+	if len(path) == 0 {
+		return "/"
+	}
+	var current interface{} = config
+	parts := strings.Split(path[1:], "/")
+	display := ""
+	for _, part := range parts {
+		key := unescapePointerSegment(part)
+		switch v := current.(type) {
+		case map[string]interface{}:
+			current = v[key]
+			display = display + "/" + part
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i >= len(v) {
+				current = nil
+				display = display + "/" + part
+				continue
+			}
+			current = v[i]
+			if m, ok := current.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok && len(name) > 0 {
+					display = display + "[name=" + name + "]"
+					continue
+				}
+			}
+			display = display + "/" + part
+		default:
+			current = nil
+			display = display + "/" + part
+		}
+	}
+	return display
+}
+
+func structuralValueAt(config map[string]interface{}, path string) string {
+	pointer, err := gojsonpointer.NewJsonPointer(path)
+	if err != nil {
+		return structuralAbsentValue
+	}
+	val, _, err := pointer.Get(config)
+	if err != nil {
+		return structuralAbsentValue
+	}
+	return structuralValue(val)
+}
+
+// structuralValue renders v as compact JSON. Maps and arrays which are too
+// long to be displayed on one line are collapsed to their size.
+func structuralValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	s := string(b)
+	if len(s) <= structuralMaxValueLen {
+		return s
+	}
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("{...} (%d keys)", len(vv))
+	case []interface{}:
+		return fmt.Sprintf("[...] (%d elements)", len(vv))
+	}
+	return s
+}
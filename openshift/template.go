@@ -14,39 +14,67 @@ import (
 )
 
 func ExportAsTemplateFile(filter *ResourceFilter, exportOptions *cli.ExportOptions) (string, error) {
-	outBytes, err := ExportResources(filter, exportOptions.Namespace)
+	m, _, err := ExportAsTemplate(filter, exportOptions.Namespace, exportOptions.StripDefaults)
 	if err != nil {
 		return "", err
 	}
-	if len(outBytes) == 0 {
+	if m == nil {
 		return "", nil
 	}
 
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf(
+			"Could not marshal modified template: %s", err,
+		)
+	}
+
+	return string(b), err
+}
+
+// ExportAsTemplate exports the targeted resources and cleans them so that
+// they can be used as a template. It returns the template as a map together
+// with the cleaned items. If no resources are found, the map is nil.
+// If stripDefaults is true, fields set to their server default are removed.
+func ExportAsTemplate(filter *ResourceFilter, namespace string, stripDefaults bool) (map[string]interface{}, []*ResourceItem, error) {
+	outBytes, err := ExportResources(filter, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(outBytes) == 0 {
+		return nil, nil, nil
+	}
+
 	var f interface{}
 	err = yaml.Unmarshal(outBytes, &f)
 	if err != nil {
 		err = utils.DisplaySyntaxError(outBytes, err)
-		return "", err
+		return nil, nil, err
 	}
 	m := f.(map[string]interface{})
 
 	objectsPointer, _ := gojsonpointer.NewJsonPointer("/objects")
 	items, _, err := objectsPointer.Get(m)
 	if err != nil {
-		return "", fmt.Errorf(
+		return nil, nil, fmt.Errorf(
 			"Could not get objects of exported template: %s", err,
 		)
 	}
+	exportedItems := []*ResourceItem{}
 	for k, v := range items.([]interface{}) {
 		item, err := NewResourceItem(v.(map[string]interface{}), "platform")
 		if err != nil {
-			return "", fmt.Errorf(
+			return nil, nil, fmt.Errorf(
 				"Could not parse object of exported template: %s", err,
 			)
 		}
 		item.RemoveUnmanagedAnnotations()
+		if stripDefaults {
+			item.RemoveServerDefaults()
+		}
 		itemPointer, _ := gojsonpointer.NewJsonPointer("/objects/" + strconv.Itoa(k))
 		_, _ = itemPointer.Set(m, item.Config)
+		exportedItems = append(exportedItems, item)
 	}
 
 	cli.DebugMsg("Remove metadata from template")
@@ -56,18 +84,15 @@ func ExportAsTemplateFile(filter *ResourceFilter, exportOptions *cli.ExportOptio
 		cli.DebugMsg("Could not delete metadata from template")
 	}
 
-	b, err := yaml.Marshal(m)
-	if err != nil {
-		return "", fmt.Errorf(
-			"Could not marshal modified template: %s", err,
-		)
-	}
-
-	return string(b), err
+	return m, exportedItems, nil
 }
 
 func ExportResources(filter *ResourceFilter, namespace string) ([]byte, error) {
 	target := filter.ConvertToKinds()
+	if len(target) == 0 {
+		cli.DebugMsg("No namespaced kinds to export")
+		return []byte{}, nil
+	}
 	args := []string{"export", target, "--output=yaml", "--as-template=tailor"}
 	cmd := cli.ExecOcCmd(
 		args,
@@ -105,9 +130,6 @@ func ProcessTemplate(templateDir string, name string, paramDir string, compareOp
 		args = append(args, "--labels="+compareOptions.Labels)
 	}
 
-	for _, param := range compareOptions.Params {
-		args = append(args, "--param="+param)
-	}
 	containsNamespace, err := templateContainsTailorNamespaceParam(filename)
 	if err != nil {
 		return []byte{}, err
@@ -116,55 +138,39 @@ func ProcessTemplate(templateDir string, name string, paramDir string, compareOp
 		args = append(args, "--param=TAILOR_NAMESPACE="+compareOptions.Namespace)
 	}
 
-	actualParamFiles := compareOptions.ParamFiles
-	// If param-file is not given, we assume a param-dir
-	if len(actualParamFiles) == 0 {
-		// Prefer <namespace> folder over current directory
-		if paramDir == "." {
-			if _, err := os.Stat(compareOptions.Namespace); err == nil {
-				paramDir = compareOptions.Namespace
-			}
-		}
-
-		cli.DebugMsg(fmt.Sprintf("Looking for param files in '%s'", paramDir))
+	// Params of the global layer are only passed if the template declares
+	// them, so we need to know the declared parameters.
+	parameters, err := ReadTemplateParameters(filename)
+	if err != nil {
+		return []byte{}, fmt.Errorf("Could not read parameters of %s: %s", filename, err)
+	}
+	declared := []string{}
+	for _, p := range parameters {
+		declared = append(declared, p.Name)
+	}
 
-		fileParts := strings.Split(name, ".")
-		fileParts[len(fileParts)-1] = "env"
-		f := paramDir + string(os.PathSeparator) + strings.Join(fileParts, ".")
-		if _, err := os.Stat(f); err == nil {
-			actualParamFiles = []string{f}
-		}
+	layers, err := ReadParamLayers(name, paramDir, compareOptions, true)
+	if err != nil {
+		return []byte{}, err
 	}
-	// Now turn the param files into arguments for the oc binary
-	if len(actualParamFiles) > 0 {
-		paramFileBytes := []byte{}
-		for _, f := range actualParamFiles {
-			cli.DebugMsg("Reading content of param file", f)
-			b, err := ioutil.ReadFile(f)
-			if err != nil {
-				return []byte{}, err
-			}
-			paramFileBytes = append(paramFileBytes, b...)
-			// Check if encrypted param file exists, and if so, decrypt and
-			// append its content
-			encFile := f + ".enc"
-			if _, err := os.Stat(encFile); err == nil {
-				cli.DebugMsg("Reading content of encrypted param file", encFile)
-				b, err := ioutil.ReadFile(encFile)
-				if err != nil {
-					return []byte{}, err
-				}
-				encoded, err := EncodedParams(string(b), compareOptions.PrivateKey, compareOptions.Passphrase)
-				if err != nil {
-					return []byte{}, err
-				}
-				paramFileBytes = append(paramFileBytes, []byte(encoded)...)
-			}
-		}
+	if conflicts := layers.Conflicts(); len(conflicts) > 0 {
+		return []byte{}, fmt.Errorf(
+			"Conflicting parameter definitions for %s:\n%s",
+			filename,
+			strings.Join(conflicts, "\n"),
+		)
+	}
+	for _, explainParam := range compareOptions.ExplainParams {
+		PrintParamExplanation(explainParam, filename, layers, parameters)
+	}
+
+	// Now turn the resolved params into arguments for the oc binary
+	paramFileContent := layers.Content(declared)
+	if len(paramFileContent) > 0 {
 		tempParamFile := ".combined.env"
 		defer os.Remove(tempParamFile)
-		cli.DebugMsg("Writing contents of param files into", tempParamFile)
-		err = ioutil.WriteFile(tempParamFile, paramFileBytes, 0644)
+		cli.DebugMsg("Writing resolved params into", tempParamFile)
+		err = ioutil.WriteFile(tempParamFile, []byte(paramFileContent), 0644)
 		if err != nil {
 			return []byte{}, err
 		}
@@ -188,6 +194,42 @@ func ProcessTemplate(templateDir string, name string, paramDir string, compareOp
 	return outBytes, err
 }
 
+// paramFilename returns the name of the param file belonging to the template
+// with given name, e.g. "foo.env" for "foo.yml".
+func paramFilename(name string) string {
+	fileParts := strings.Split(name, ".")
+	fileParts[len(fileParts)-1] = "env"
+	return strings.Join(fileParts, ".")
+}
+
+// PrintParamExplanation prints which layer provides the final value of given
+// parameter. Nothing is printed if the template neither declares the
+// parameter nor does any layer define it.
+func PrintParamExplanation(name string, filename string, layers ParamLayers, parameters []*TemplateParameter) {
+	var parameter *TemplateParameter
+	declared := []string{}
+	for _, p := range parameters {
+		declared = append(declared, p.Name)
+		if p.Name == name {
+			parameter = p
+		}
+	}
+	explanation := layers.Explain(name, declared)
+	if parameter == nil && len(explanation) == 0 {
+		return
+	}
+	fmt.Printf("Parameter %s of %s:\n", name, filename)
+	if len(explanation) > 0 {
+		fmt.Print(explanation)
+	}
+	if parameter == nil {
+		fmt.Println("  not declared by template")
+	} else if _, ok := layers.Resolve(declared)[name]; !ok {
+		fmt.Printf("  not set in any layer, %s\n", parameter.Status())
+	}
+	fmt.Println("")
+}
+
 // Returns true if template contains a param like "name: TAILOR_NAMESPACE"
 func templateContainsTailorNamespaceParam(filename string) (bool, error) {
 	b, err := ioutil.ReadFile(filename)
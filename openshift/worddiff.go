@@ -0,0 +1,272 @@
+package openshift
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ghodss/yaml"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/xeipuuv/gojsonpointer"
+)
+
+const (
+	wordDiffContext       = 3
+	sideBySideSeparator   = " | "
+	sideBySideMinColWidth = 20
+)
+
+var (
+	wordTokenPattern = regexp.MustCompile(`\s+|[\p{L}\p{N}_]+|.`)
+)
+
+type sideBySideRow struct {
+	left  string
+	right string
+}
+
+// WordDiff renders each patch of the change with word-level highlighting:
+// removed words are wrapped in "[-...-]" and added words in "{+...+}".
+// Values spanning multiple lines (e.g. files embedded in a ConfigMap) are
+// shown as a unified diff of their content instead. If sideBySide is true,
+// current and desired value are shown next to each other, using width
+// columns in total.
+func (c *Change) WordDiff(sideBySide bool, width int) string {
+	current := map[string]interface{}{}
+	desired := map[string]interface{}{}
+	_ = yaml.Unmarshal([]byte(c.CurrentState), &current)
+	_ = yaml.Unmarshal([]byte(c.DesiredState), &desired)
+
+	var sb strings.Builder
+	for _, p := range c.Patches {
+		oldValue := ""
+		newValue := ""
+		context := current
+		switch p.Op {
+		case "add":
+			context = desired
+			newValue = wordDiffValue(p.Value)
+		case "replace":
+			oldValue = wordDiffValueAt(current, p.Path)
+			newValue = wordDiffValue(p.Value)
+		case "remove":
+			oldValue = wordDiffValueAt(current, p.Path)
+		default:
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s:\n", structuralPath(p.Path, context))
+		multiLine := strings.Contains(oldValue, "\n") || strings.Contains(newValue, "\n")
+		if sideBySide {
+			sb.WriteString(renderSideBySide(sideBySideRows(oldValue, newValue, multiLine), width))
+		} else if multiLine {
+			sb.WriteString(innerUnifiedDiff(oldValue, newValue))
+		} else {
+			sb.WriteString("    " + highlightWords(oldValue, newValue) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func wordDiffValueAt(config map[string]interface{}, path string) string {
+	pointer, err := gojsonpointer.NewJsonPointer(path)
+	if err != nil {
+		return ""
+	}
+	val, _, err := pointer.Get(config)
+	if err != nil {
+		return ""
+	}
+	return wordDiffValue(val)
+}
+
+// wordDiffValue returns strings as-is and everything else as compact JSON.
+func wordDiffValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func splitWords(s string) []string {
+	return wordTokenPattern.FindAllString(s, -1)
+}
+
+// highlightWords returns one line showing both a and b, in which the words
+// only present in a are wrapped in "[-...-]" and the words only present in
+// b are wrapped in "{+...+}".
+func highlightWords(a, b string) string {
+	left, right := splitWords(a), splitWords(b)
+	var sb strings.Builder
+	m := difflib.NewMatcherWithJunk(left, right, false, nil)
+	for _, op := range m.GetOpCodes() {
+		removed := strings.Join(left[op.I1:op.I2], "")
+		added := strings.Join(right[op.J1:op.J2], "")
+		switch op.Tag {
+		case 'e':
+			sb.WriteString(removed)
+		case 'd':
+			sb.WriteString("[-" + removed + "-]")
+		case 'i':
+			sb.WriteString("{+" + added + "+}")
+		case 'r':
+			sb.WriteString("[-" + removed + "-]{+" + added + "+}")
+		}
+	}
+	return sb.String()
+}
+
+// highlightWordsSeparately works like highlightWords, but returns a and b
+// separately, for display in two columns.
+func highlightWordsSeparately(a, b string) (string, string) {
+	left, right := splitWords(a), splitWords(b)
+	var lsb, rsb strings.Builder
+	m := difflib.NewMatcherWithJunk(left, right, false, nil)
+	for _, op := range m.GetOpCodes() {
+		removed := strings.Join(left[op.I1:op.I2], "")
+		added := strings.Join(right[op.J1:op.J2], "")
+		switch op.Tag {
+		case 'e':
+			lsb.WriteString(removed)
+			rsb.WriteString(added)
+		case 'd':
+			lsb.WriteString("[-" + removed + "-]")
+		case 'i':
+			rsb.WriteString("{+" + added + "+}")
+		case 'r':
+			lsb.WriteString("[-" + removed + "-]")
+			rsb.WriteString("{+" + added + "+}")
+		}
+	}
+	return lsb.String(), rsb.String()
+}
+
+func innerUnifiedDiff(a, b string) string {
+	diff := difflib.UnifiedDiff{
+		A:        splitValueLines(a),
+		B:        splitValueLines(b),
+		FromFile: "Current value",
+		ToFile:   "Desired value",
+		Context:  wordDiffContext,
+	}
+	text, _ := difflib.GetUnifiedDiffString(diff)
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if len(line) > 0 {
+			sb.WriteString("    " + line)
+		}
+	}
+	return sb.String()
+}
+
+// splitValueLines splits s into lines, each ending in a newline.
+func splitValueLines(s string) []string {
+	if len(s) == 0 {
+		return []string{}
+	}
+	return difflib.SplitLines(strings.TrimSuffix(s, "\n"))
+}
+
+// sideBySideRows pairs up the lines of a and b. Changed lines are
+// highlighted on word level. For multi-line values, only changed lines and
+// their context are included.
+func sideBySideRows(a, b string, multiLine bool) []sideBySideRow {
+	if !multiLine {
+		l, r := highlightWordsSeparately(a, b)
+		return []sideBySideRow{{left: l, right: r}}
+	}
+	left := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	right := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+	if len(a) == 0 {
+		left = []string{}
+	}
+	if len(b) == 0 {
+		right = []string{}
+	}
+	rows := []sideBySideRow{}
+	m := difflib.NewMatcherWithJunk(left, right, false, nil)
+	for i, group := range m.GetGroupedOpCodes(wordDiffContext) {
+		if i > 0 {
+			rows = append(rows, sideBySideRow{left: "...", right: "..."})
+		}
+		for _, op := range group {
+			switch op.Tag {
+			case 'e':
+				for k := op.I1; k < op.I2; k++ {
+					rows = append(rows, sideBySideRow{left: left[k], right: left[k]})
+				}
+			case 'd':
+				for k := op.I1; k < op.I2; k++ {
+					rows = append(rows, sideBySideRow{left: "[-" + left[k] + "-]"})
+				}
+			case 'i':
+				for k := op.J1; k < op.J2; k++ {
+					rows = append(rows, sideBySideRow{right: "{+" + right[k] + "+}"})
+				}
+			case 'r':
+				n := op.I2 - op.I1
+				if op.J2-op.J1 > n {
+					n = op.J2 - op.J1
+				}
+				for k := 0; k < n; k++ {
+					i, j := op.I1+k, op.J1+k
+					switch {
+					case i < op.I2 && j < op.J2:
+						l, r := highlightWordsSeparately(left[i], right[j])
+						rows = append(rows, sideBySideRow{left: l, right: r})
+					case i < op.I2:
+						rows = append(rows, sideBySideRow{left: "[-" + left[i] + "-]"})
+					default:
+						rows = append(rows, sideBySideRow{right: "{+" + right[j] + "+}"})
+					}
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// renderSideBySide renders rows in two columns which together fill width.
+// Content which does not fit into a column is wrapped.
+func renderSideBySide(rows []sideBySideRow, width int) string {
+	colWidth := (width - 4 - len(sideBySideSeparator)) / 2
+	if colWidth < sideBySideMinColWidth {
+		colWidth = sideBySideMinColWidth
+	}
+	var sb strings.Builder
+	for _, row := range rows {
+		left := wrapText(row.left, colWidth)
+		right := wrapText(row.right, colWidth)
+		for len(left) < len(right) {
+			left = append(left, "")
+		}
+		for len(right) < len(left) {
+			right = append(right, "")
+		}
+		for k := range left {
+			padding := strings.Repeat(" ", colWidth-utf8.RuneCountInString(left[k]))
+			line := "    " + left[k] + padding + sideBySideSeparator + right[k]
+			sb.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// wrapText splits s into chunks of at most width runes.
+func wrapText(s string, width int) []string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return []string{""}
+	}
+	lines := []string{}
+	for len(runes) > width {
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(lines, string(runes))
+}
@@ -0,0 +1,89 @@
+package openshift
+
+import (
+	"testing"
+)
+
+func TestHighlightWords(t *testing.T) {
+	tests := map[string]struct {
+		a        string
+		b        string
+		expected string
+	}{
+		"changed option": {
+			a:        "-Xms128m -Xmx256m -Dfoo=bar",
+			b:        "-Xms128m -Xmx512m -Dfoo=bar",
+			expected: "-Xms128m -[-Xmx256m-]{+Xmx512m+} -Dfoo=bar",
+		},
+		"added option": {
+			a:        "-Xmx256m",
+			b:        "-Xmx256m -Dfoo=bar",
+			expected: "-Xmx256m{+ -Dfoo=bar+}",
+		},
+		"removed value": {
+			a:        "foo",
+			b:        "",
+			expected: "[-foo-]",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := highlightWords(tc.a, tc.b)
+			if actual != tc.expected {
+				t.Errorf("Got %s, want %s", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	c := &Change{
+		Action: "Update",
+		Kind:   "ConfigMap",
+		Name:   "foo",
+		Patches: []*jsonPatch{
+			&jsonPatch{Op: "replace", Path: "/data/application.properties", Value: "a=1\nb=3\nc=3\n"},
+			&jsonPatch{Op: "replace", Path: "/data/opts", Value: "-Xmx512m -Dfoo=bar"},
+		},
+		CurrentState: `apiVersion: v1
+data:
+  application.properties: |
+    a=1
+    b=2
+    c=3
+  opts: -Xmx256m -Dfoo=bar
+kind: ConfigMap
+metadata:
+  name: foo
+`,
+	}
+
+	expected := `  /data/application.properties:
+    --- Current value
+    +++ Desired value
+    @@ -1,3 +1,3 @@
+     a=1
+    -b=2
+    +b=3
+     c=3
+  /data/opts:
+    -[-Xmx256m-]{+Xmx512m+} -Dfoo=bar
+`
+	actual := c.WordDiff(false, 0)
+	if actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+
+	expected = `  /data/application.properties:
+    a=1                  | a=1
+    b=[-2-]              | b={+3+}
+    c=3                  | c=3
+  /data/opts:
+    -[-Xmx256m-] -Dfoo=b | -{+Xmx512m+} -Dfoo=b
+    ar                   | ar
+`
+	actual = c.WordDiff(true, 40)
+	if actual != expected {
+		t.Errorf("Got:\n%s\nwant:\n%s", actual, expected)
+	}
+}